@@ -0,0 +1,194 @@
+package twigots
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Cassettes are directories of recorded requests and responses, which can be used to replay
+// real sessions (e.g. fetching several feed pages) in tests without network access.
+//
+// Each request is recorded to its own JSON file, named by the request path and its
+// q and maxTime query parameters. The api key is redacted from recorded urls.
+
+// cassetteEntry is a recorded request and response.
+type cassetteEntry struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method  string `json:"method"`
+	URL     string `json:"url"` // Api key is redacted
+	Query   string `json:"q,omitempty"`
+	MaxTime string `json:"maxTime,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode  int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+
+	// Body of the response. JSON bodies are stored as is, to keep cassettes readable.
+	BodyJSON json.RawMessage `json:"bodyJson,omitempty"`
+	Body     string          `json:"body,omitempty"`
+}
+
+func (r cassetteResponse) body() []byte {
+	if len(r.BodyJSON) != 0 {
+		return r.BodyJSON
+	}
+	return []byte(r.Body)
+}
+
+var unsafeCassetteNameCharacters = regexp.MustCompile(`[^A-Za-z0-9=,._-]+`)
+
+// cassetteFileName gets the name of the file a request is recorded to.
+// Requests are matched on their path, and q and maxTime query parameters.
+func cassetteFileName(request *http.Request) string {
+	queryParams := request.URL.Query()
+	nameParts := []string{
+		strings.Trim(request.URL.Path, "/"),
+		queryParams.Get("q"),
+		queryParams.Get("maxTime"),
+	}
+
+	name := strings.Join(nameParts, "_")
+	name = unsafeCassetteNameCharacters.ReplaceAllString(name, "-")
+	return name + ".json"
+}
+
+// recordingTransport records requests and responses to a cassette directory.
+type recordingTransport struct {
+	directory string
+	next      http.RoundTripper
+}
+
+// NewRecordingTransport creates a transport that makes requests using the next transport,
+// and records each request and successful round trip to a cassette directory.
+// Recordings of the same request are overwritten. The api key is never recorded.
+//
+// If next is nil, http.DefaultTransport is used. See WithRecording to record requests made by a Client.
+func NewRecordingTransport(directory string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{directory: directory, next: next}
+}
+
+func (t *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response to record: %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := cassetteEntry{
+		Request: cassetteRequest{
+			Method:  request.Method,
+			URL:     redactFeedUrl(request.URL.String()),
+			Query:   request.URL.Query().Get("q"),
+			MaxTime: request.URL.Query().Get("maxTime"),
+		},
+		Response: cassetteResponse{
+			StatusCode:  response.StatusCode,
+			ContentType: response.Header.Get("Content-Type"),
+		},
+	}
+	if json.Valid(body) {
+		entry.Response.BodyJSON = body
+	} else {
+		entry.Response.Body = string(body)
+	}
+
+	err = t.save(cassetteFileName(request), entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (t *recordingTransport) save(fileName string, entry cassetteEntry) error {
+	entryJson, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	err = os.MkdirAll(t.directory, 0o755)
+	if err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(t.directory, fileName), append(entryJson, '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return nil
+}
+
+// replayTransport serves responses from a cassette directory.
+type replayTransport struct {
+	directory string
+}
+
+// NewReplayTransport creates a transport that serves responses recorded by NewRecordingTransport,
+// without making any real requests. Requests are matched to recordings by their path, and their
+// q and maxTime query parameters. An error is returned for requests that have not been recorded.
+//
+// See WithReplay to replay requests made by a Client.
+func NewReplayTransport(directory string) http.RoundTripper {
+	return &replayTransport{directory: directory}
+}
+
+func (t *replayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		_ = request.Body.Close()
+	}
+
+	fileName := cassetteFileName(request)
+	entryJson, err := os.ReadFile(filepath.Join(t.directory, fileName))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"no recording of request %s %s: %w",
+			request.Method, redactFeedUrl(request.URL.String()), err,
+		)
+	}
+
+	var entry cassetteEntry
+	err = json.Unmarshal(entryJson, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recording %s: %w", fileName, err)
+	}
+
+	body := entry.Response.body()
+	response := &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.StatusCode, http.StatusText(entry.Response.StatusCode)),
+		StatusCode:    entry.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+	if entry.Response.ContentType != "" {
+		response.Header.Set("Content-Type", entry.Response.ContentType)
+	}
+
+	return response, nil
+}
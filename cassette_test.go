@@ -0,0 +1,93 @@
+package twigots_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+	cassetteDirectory := filepath.Join(t.TempDir(), "cassette")
+	input := twigots.FetchTicketListingsInput{
+		Country:       twigots.CountryUnitedKingdom,
+		MaxNumber:     15,
+		CreatedBefore: testTime,
+	}
+
+	// Record a paginated session against a mock feed
+	mockTransport := httpmock.NewMockTransport()
+	url1, responder1 := getMockUrlAndResponder(t, testEvents[:10], testTime, time.Minute)
+	url2, responder2 := getMockUrlAndResponder(t, testEvents[10:20], testTime.Add(-10*time.Minute), time.Minute)
+	mockTransport.RegisterResponder("GET", url1, responder1)
+	mockTransport.RegisterResponder("GET", url2, responder2)
+
+	recordingClient, err := twigots.NewClient(testAPIKey)
+	require.NoError(t, err)
+	recordingClient.Client().Transport = twigots.NewRecordingTransport(cassetteDirectory, mockTransport)
+
+	recordedListings, err := recordingClient.FetchTicketListings(context.Background(), input)
+	require.NoError(t, err)
+	require.Len(t, recordedListings, 15)
+
+	// Each page should be recorded, without the api key
+	recordings, err := os.ReadDir(cassetteDirectory)
+	require.NoError(t, err)
+	require.Len(t, recordings, 2)
+	for _, recording := range recordings {
+		recordingJson, err := os.ReadFile(filepath.Join(cassetteDirectory, recording.Name()))
+		require.NoError(t, err)
+		require.NotContains(t, string(recordingJson), "api_key="+testAPIKey)
+		require.Contains(t, string(recordingJson), "api_key=REDACTED")
+	}
+
+	// Replaying the session should give the same listings, without any real requests
+	replayClient, err := twigots.NewClient("different", twigots.WithReplay(cassetteDirectory))
+	require.NoError(t, err)
+
+	replayedListings, err := replayClient.FetchTicketListings(context.Background(), input)
+	require.NoError(t, err)
+	require.Equal(t, recordedListings, replayedListings)
+
+	// Requests that were not recorded should fail
+	input.CreatedBefore = testTime.Add(-time.Hour)
+	_, err = replayClient.FetchTicketListings(context.Background(), input)
+	require.Error(t, err)
+}
+
+func TestRecordReplayErrorResponse(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+	cassetteDirectory := t.TempDir()
+	input := twigots.FetchTicketListingsInput{
+		Country:       twigots.CountryUnitedKingdom,
+		CreatedBefore: testTime,
+	}
+
+	mockTransport := httpmock.NewMockTransport()
+	url, _ := getMockUrlAndResponder(t, nil, testTime, time.Minute)
+	mockTransport.RegisterResponder(
+		"GET", url,
+		httpmock.NewStringResponder(http.StatusForbidden, "<html>Access Denied</html>"),
+	)
+
+	recordingClient, err := twigots.NewClient(testAPIKey)
+	require.NoError(t, err)
+	recordingClient.Client().Transport = twigots.NewRecordingTransport(cassetteDirectory, mockTransport)
+
+	_, err = recordingClient.FetchTicketListings(context.Background(), input)
+	require.ErrorContains(t, err, "Access Denied")
+
+	// Non JSON responses should also be replayed
+	replayClient, err := twigots.NewClient(testAPIKey, twigots.WithReplay(cassetteDirectory))
+	require.NoError(t, err)
+
+	_, err = replayClient.FetchTicketListings(context.Background(), input)
+	require.ErrorContains(t, err, "Access Denied")
+}
@@ -5,16 +5,33 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/imroc/req/v3"
 	"github.com/k3a/html2text"
+	"golang.org/x/time/rate"
 )
 
 type Client struct {
-	client *req.Client
-	apiKey string
+	client  *req.Client
+	apiKey  string
+	metrics Metrics
+	tracer  Tracer
+	logger  *slog.Logger
+	limiter *rate.Limiter
+
+	// Rate limiter used by FetchTicketListingsFanOut. This is the same as limiter if
+	// a rate limit is set, otherwise it is the recommended rate limit. See WithRateLimit.
+	fanOutLimiter *rate.Limiter
+
+	// Maximum number of times failed requests are retried. See WithRetries.
+	maxRetries int
+
+	// Lenient decoding options. See WithLenientDecoding.
+	lenientDecoding bool
+	onDecodeError   func(FeedDecodeError)
 }
 
 func (c *Client) Client() *http.Client {
@@ -75,6 +92,11 @@ func (f FetchTicketListingsInput) Validate() error {
 	if !Countries.Contains(f.Country) {
 		return fmt.Errorf("country '%s' is not valid", f.Country)
 	}
+	for _, region := range f.Regions {
+		if Regions.Contains(region) && region.Country() != f.Country {
+			return fmt.Errorf("region '%s' is not in country '%s'", region.Value, f.Country.Value)
+		}
+	}
 	if f.CreatedBefore.Before(f.CreatedAfter) {
 		return errors.New("created after time must be after the created before time")
 	}
@@ -89,14 +111,82 @@ func (c *Client) FetchTicketListingsByFeedUrl(
 	ctx context.Context,
 	feedUrl string,
 ) (TicketListings, error) {
-	response, err := c.client.R().SetContext(ctx).Get(feedUrl)
+	page, err := c.fetchFeedPage(ctx, feedUrl)
+	return page.listings, err
+}
+
+// fetchFeedPage fetches a feed page using the specified feed url, recording traces, metrics and logs.
+func (c *Client) fetchFeedPage(ctx context.Context, feedUrl string) (feedPage, error) {
+	feedRequest := newFeedRequest(feedUrl)
+	ctx, endTrace := c.tracer.StartFeedRequest(ctx, feedRequest)
+
+	startTime := time.Now()
+	page, statusCode, err := c.fetchFeed(ctx, feedUrl)
+	feedResponse := FeedResponse{
+		StatusCode:      statusCode,
+		NumListings:     len(page.listings),
+		NumDecodeErrors: len(page.decodeErrors),
+		Duration:        time.Since(startTime),
+		Err:             err,
+	}
+
+	endTrace(feedResponse)
+	c.metrics.ObserveFeedRequest(feedRequest, feedResponse)
+	c.logFeedResponse(ctx, feedUrl, page, feedResponse)
+
+	for _, decodeErr := range page.decodeErrors {
+		c.logger.WarnContext(ctx, "skipped feed entry that failed to decode",
+			slog.String("url", redactFeedUrl(feedUrl)),
+			slog.Int("index", decodeErr.Index),
+			slog.String("raw", string(decodeErr.Raw)),
+			slog.Any("error", decodeErr.Err),
+		)
+		if c.onDecodeError != nil {
+			c.onDecodeError(decodeErr)
+		}
+	}
+
+	return page, err
+}
+
+func (c *Client) logFeedResponse(ctx context.Context, feedUrl string, page feedPage, response FeedResponse) {
+	attrs := []slog.Attr{
+		slog.String("url", redactFeedUrl(feedUrl)),
+		slog.Int("status", response.StatusCode),
+		slog.Duration("duration", response.Duration),
+	}
+	if response.Err != nil {
+		attrs = append(attrs, slog.Any("error", response.Err))
+		c.logger.LogAttrs(ctx, slog.LevelError, "failed to fetch feed page", attrs...)
+		return
+	}
+
+	attrs = append(attrs,
+		slog.Int("listings", len(page.listings)),
+		slog.Int("delists", len(page.delists)),
+		slog.Int("decodeErrors", len(page.decodeErrors)),
+	)
+	if len(page.listings) != 0 {
+		// Feed listings are ordered newest first
+		attrs = append(attrs,
+			slog.Time("earliestCreatedAt", page.listings[len(page.listings)-1].CreatedAt.Time),
+			slog.Time("latestCreatedAt", page.listings[0].CreatedAt.Time),
+		)
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "fetched feed page", attrs...)
+}
+
+// fetchFeed fetches a feed page, returning the page and the response status code.
+// Failed requests are retried if enabled (see WithRetries), with each retry also rate limited.
+func (c *Client) fetchFeed(ctx context.Context, feedUrl string) (feedPage, int, error) {
+	response, err := c.getWithRetries(ctx, feedUrl)
 	if err != nil {
-		return nil, nil
+		return feedPage{}, 0, fmt.Errorf("failed to fetch tickets: %w", err)
 	}
 
 	if !response.IsSuccessState() {
 		errorBody := html2text.HTML2Text(response.String())
-		return nil, fmt.Errorf(
+		return feedPage{}, response.StatusCode, fmt.Errorf(
 			"failed to fetch tickets: %s\n\nResponse:\n%s",
 			response.GetStatus(), errorBody,
 		)
@@ -104,10 +194,50 @@ func (c *Client) FetchTicketListingsByFeedUrl(
 
 	bodyBytes, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return feedPage{}, response.StatusCode, err
 	}
 
-	return UnmarshalTwicketsFeedJson(bodyBytes)
+	page, err := unmarshalFeedPage(bodyBytes, c.lenientDecoding)
+	return page, response.StatusCode, err
+}
+
+// getWithRetries makes a rate limited get request to a url, retrying failed requests
+// up to the client's maximum number of retries with backoff.
+func (c *Client) getWithRetries(ctx context.Context, apiUrl string) (*req.Response, error) {
+	for attempt := 0; ; attempt++ {
+		err := c.limiter.Wait(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for rate limiter: %w", err)
+		}
+
+		response, err := c.client.R().SetContext(ctx).Get(apiUrl)
+		if err != nil {
+			err = redactError(err)
+		}
+		if attempt >= c.maxRetries || !shouldRetry(response, err) {
+			return response, err
+		}
+
+		attrs := []any{
+			slog.Int("attempt", attempt+1),
+			slog.String("url", redactFeedUrl(apiUrl)),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		if response != nil && response.Response != nil {
+			attrs = append(attrs, slog.Int("status", response.StatusCode))
+		}
+		c.logger.WarnContext(ctx, "retrying feed request", attrs...)
+
+		timer := time.NewTimer(retryBackoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
 }
 
 // FetchTicketListings gets ticket listings using the specified input.
@@ -115,17 +245,48 @@ func (c *Client) FetchTicketListings(
 	ctx context.Context,
 	input FetchTicketListingsInput,
 ) (TicketListings, error) {
+	listings, _, err := c.FetchTicketListingsAndDelists(ctx, input)
+	return listings, err
+}
+
+// FetchTicketListingsAndDelists gets ticket listings using the specified input, along with the ticket
+// listings delisted in the fetched feed pages. Only delists after the `CreatedAfter` input are returned.
+func (c *Client) FetchTicketListingsAndDelists(
+	ctx context.Context,
+	input FetchTicketListingsInput,
+) (TicketListings, []Delist, error) {
+	startTime := time.Now()
+	listings, delists, numPages, err := c.fetchTicketListings(ctx, input)
+	c.metrics.ObserveFetch(FetchResult{
+		Input:    input,
+		NumPages: numPages,
+		Listings: listings,
+		Duration: time.Since(startTime),
+		Err:      err,
+	})
+	return listings, delists, err
+}
+
+// fetchTicketListings gets ticket listings and delists using the specified input,
+// returning the listings, delists and the number of feed pages fetched.
+func (c *Client) fetchTicketListings(
+	ctx context.Context,
+	input FetchTicketListingsInput,
+) (TicketListings, []Delist, int, error) {
 	input.applyDefaults()
 	err := input.Validate()
 	if err != nil {
-		return nil, fmt.Errorf("invalid input: %w", err)
+		return nil, nil, 0, fmt.Errorf("invalid input: %w", err)
 	}
 
 	// Iterate through feeds until have the number of listings desired
 	// or listings creation time is before the created after input
 	listings := make(TicketListings, 0, input.MaxNumber)
+	var delists []Delist
+	delistIds := make(map[string]struct{})
 	earliestTicketTime := input.CreatedBefore
 	numListingsRemaining := input.MaxNumber
+	numPages := 0
 	for {
 
 		// Get feed url
@@ -136,51 +297,81 @@ func (c *Client) FetchTicketListings(
 			BeforeTime: earliestTicketTime,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get feed url: %w", err)
+			return nil, nil, numPages, fmt.Errorf("failed to get feed url: %w", err)
 		}
 
 		// Fetch new listings
-		newListings, err := c.FetchTicketListingsByFeedUrl(ctx, feedUrl)
+		page, err := c.fetchFeedPage(ctx, feedUrl)
+		numPages++
 		if err != nil {
-			return nil, err
+			return nil, nil, numPages, err
 		}
+		newListings := page.listings
 		if len(newListings) == 0 {
-			return nil, errors.New("no listings returned")
+			c.logger.WarnContext(ctx, "stopping fetch: no listings returned", slog.Int("pages", numPages))
+			return nil, nil, numPages, errors.New("no listings returned")
+		}
+
+		// Keep delists after the created after time. Pages can overlap, so delists are deduplicated.
+		for _, delist := range page.delists {
+			if _, ok := delistIds[delist.Id]; ok {
+				continue
+			}
+			if !delist.DelistedAt.IsZero() && !delist.DelistedAt.After(input.CreatedAfter) {
+				continue
+			}
+			delistIds[delist.Id] = struct{}{}
+			delists = append(delists, delist)
 		}
 
 		// Process listings, ignoring those created too early.
-		// Will return shouldBreak if a break condition is met.
-		processedListings, shouldBreak := processFeedListings(
+		// Will return a stop reason if a break condition is met.
+		processedListings, stopReason := processFeedListings(
 			newListings, numListingsRemaining, input.CreatedAfter,
 		)
 
 		// Update listings
 		listings = append(listings, processedListings...)
-		if shouldBreak {
+		if stopReason != "" {
+			c.logger.DebugContext(ctx, "stopping fetch: "+stopReason,
+				slog.Int("pages", numPages),
+				slog.Int("listings", len(listings)),
+			)
 			break
 		}
+		c.logger.DebugContext(ctx, "continuing fetch: stop condition not met",
+			slog.Int("pages", numPages),
+			slog.Int("listings", len(listings)),
+		)
 
 		// Update loop variables
 		earliestTicketTime = listings[len(listings)-1].CreatedAt.Time
 		numListingsRemaining = input.MaxNumber - len(listings)
 	}
 
-	return listings, nil
+	return listings, delists, numPages, nil
 }
 
+// Reasons for stopping iteration through feed pages.
+const (
+	stopReasonCreatedAfter = "listing not created after created after time"
+	stopReasonMaxNumber    = "max number of listings reached"
+)
+
 // processFeedListings, ignoring those created too early.
-// Returns the processed ticket listings, an whether iteration should continue.
+// Returns the processed ticket listings, and the reason iteration should stop.
+// The reason is empty if iteration should continue.
 func processFeedListings(
 	listings TicketListings,
 	maxNumber int,
 	createdAfter time.Time,
-) ([]TicketListing, bool) {
+) ([]TicketListing, string) {
 	processedListings := make([]TicketListing, 0, len(listings))
 	for _, listing := range listings {
 
 		// If listing NOT created after the earliest allowed time, break
 		if !listing.CreatedAt.After(createdAfter) {
-			return processedListings, true
+			return processedListings, stopReasonCreatedAfter
 		}
 
 		// Update processes listings
@@ -188,22 +379,32 @@ func processFeedListings(
 
 		// If number of listings matches the max number, break
 		if len(processedListings) == maxNumber {
-			return processedListings, true
+			return processedListings, stopReasonMaxNumber
 		}
 	}
 
-	return processedListings, false
+	return processedListings, ""
 }
 
 // NewClient creates a new Twickets client
-func NewClient(apiKey string) (*Client, error) {
+func NewClient(apiKey string, options ...ClientOption) (*Client, error) {
 	if apiKey == "" {
 		return nil, errors.New("api key must be set")
 	}
 
-	client := req.C().ImpersonateChrome()
-	return &Client{
-		client: client,
-		apiKey: apiKey,
-	}, nil
+	client := &Client{
+		client:  req.C().ImpersonateChrome(),
+		apiKey:  apiKey,
+		metrics: noopMetrics{},
+		tracer:  noopTracer{},
+		logger:  slog.New(slog.DiscardHandler),
+		limiter: rate.NewLimiter(rate.Inf, 0),
+
+		fanOutLimiter: rate.NewLimiter(RecommendedRateLimit, RecommendedRateLimitBurst),
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	return client, nil
 }
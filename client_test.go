@@ -1,9 +1,11 @@
 package twigots_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"os"
@@ -214,6 +216,197 @@ func TestFetchListingsCreatedAfterLatestListingReturnsNothing(t *testing.T) {
 	require.Empty(t, listings)
 }
 
+// recordingInstrumentation records feed requests and fetches observed by a client.
+type recordingInstrumentation struct {
+	tracedRequests []twigots.FeedRequest
+	feedRequests   []twigots.FeedRequest
+	feedResponses  []twigots.FeedResponse
+	fetches        []twigots.FetchResult
+}
+
+func (r *recordingInstrumentation) StartFeedRequest(
+	ctx context.Context,
+	request twigots.FeedRequest,
+) (context.Context, func(twigots.FeedResponse)) {
+	r.tracedRequests = append(r.tracedRequests, request)
+	return ctx, func(twigots.FeedResponse) {}
+}
+
+func (r *recordingInstrumentation) ObserveFeedRequest(request twigots.FeedRequest, response twigots.FeedResponse) {
+	r.feedRequests = append(r.feedRequests, request)
+	r.feedResponses = append(r.feedResponses, response)
+}
+
+func (r *recordingInstrumentation) ObserveFetch(result twigots.FetchResult) {
+	r.fetches = append(r.fetches, result)
+}
+
+func TestFetchListingsInstrumentation(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+
+	// Create client
+	instrumentation := &recordingInstrumentation{}
+	twicketsClient, err := twigots.NewClient(
+		testAPIKey,
+		twigots.WithMetrics(instrumentation),
+		twigots.WithTracer(instrumentation),
+	)
+	require.NoError(t, err)
+
+	// Setup mock
+	testTime1 := testTime
+	testTime2 := testTime1.Add(-10 * time.Minute)
+	url1, responder1 := getMockUrlAndResponder(t, testEvents[:10], testTime1, time.Minute)
+	url2, responder2 := getMockUrlAndResponder(t, testEvents[10:20], testTime2, time.Minute)
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	httpmock.RegisterResponder("GET", url1, responder1)
+	httpmock.RegisterResponder("GET", url2, responder2)
+
+	listings, err := twicketsClient.FetchTicketListings(
+		context.Background(),
+		twigots.FetchTicketListingsInput{
+			Country:       twigots.CountryUnitedKingdom,
+			MaxNumber:     15,
+			CreatedBefore: testTime,
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, listings, 15)
+
+	// Each page request should be traced and observed
+	require.Equal(t, instrumentation.feedRequests, instrumentation.tracedRequests)
+	require.Equal(t, []twigots.FeedRequest{
+		{Query: "countryCode=GB", MaxTime: testTime1},
+		{Query: "countryCode=GB", MaxTime: testTime2},
+	}, instrumentation.feedRequests)
+	require.Len(t, instrumentation.feedResponses, 2)
+	for _, response := range instrumentation.feedResponses {
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, 10, response.NumListings)
+		require.NoError(t, response.Err)
+	}
+
+	require.Len(t, instrumentation.fetches, 1)
+	require.Equal(t, 2, instrumentation.fetches[0].NumPages)
+	require.Len(t, instrumentation.fetches[0].Listings, 15)
+	require.NoError(t, instrumentation.fetches[0].Err)
+}
+
+func TestFetchListingsLogging(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+
+	// Create client
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	twicketsClient, err := twigots.NewClient(
+		testAPIKey,
+		twigots.WithLogger(logger),
+		twigots.WithRetries(1),
+	)
+	require.NoError(t, err)
+
+	// Setup mock. The first request fails and should be retried
+	url, responder := getMockUrlAndResponder(t, testEvents[:10], testTime, time.Minute)
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	httpmock.RegisterResponder("GET", url, httpmock.ResponderFromResponse(
+		httpmock.NewStringResponse(http.StatusInternalServerError, "error"),
+	).Then(responder))
+
+	listings, err := twicketsClient.FetchTicketListings(
+		context.Background(),
+		twigots.FetchTicketListingsInput{
+			Country:       twigots.CountryUnitedKingdom,
+			MaxNumber:     5,
+			CreatedBefore: testTime,
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, listings, 5)
+
+	output := logs.String()
+	require.NotContains(t, output, "api_key="+testAPIKey)
+	require.Contains(t, output, "api_key=REDACTED")
+	require.Contains(t, output, `msg="retrying feed request"`)
+	require.Contains(t, output, "status=500")
+	require.Contains(t, output, `msg="fetched feed page"`)
+	require.Contains(t, output, "listings=10 delists=5")
+	require.Contains(t, output, "earliestCreatedAt="+testTime.Add(-10*time.Minute).Format("2006-01-02T15:04:05.000Z07:00"))
+	require.Contains(t, output, "latestCreatedAt="+testTime.Add(-time.Minute).Format("2006-01-02T15:04:05.000Z07:00"))
+	require.Contains(t, output, `msg="stopping fetch: max number of listings reached"`)
+}
+
+func TestFetchListingsLenientDecoding(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+
+	// Create client
+	var decodeErrors []twigots.FeedDecodeError
+	twicketsClient, err := twigots.NewClient(
+		testAPIKey,
+		twigots.WithLenientDecoding(func(decodeErr twigots.FeedDecodeError) {
+			decodeErrors = append(decodeErrors, decodeErr)
+		}),
+	)
+	require.NoError(t, err)
+
+	// Setup mock, with a malformed listing at the start of the feed
+	url, _ := getMockUrlAndResponder(t, nil, testTime, time.Minute)
+	response := getMockResponse(testEvents[:10], testTime, time.Minute)
+	response["responseData"] = append(
+		[]any{map[string]any{"catalogBlockSummary": map[string]any{"ticketQuantity": "two"}}},
+		response["responseData"].([]any)...,
+	)
+	responseJson, err := json.Marshal(response)
+	require.NoError(t, err)
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	httpmock.RegisterResponder("GET", url, httpmock.NewBytesResponder(http.StatusOK, responseJson))
+
+	listings, err := twicketsClient.FetchTicketListings(
+		context.Background(),
+		twigots.FetchTicketListingsInput{
+			Country:       twigots.CountryUnitedKingdom,
+			MaxNumber:     5,
+			CreatedBefore: testTime,
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, listings, 5)
+
+	require.Len(t, decodeErrors, 1)
+	require.Equal(t, 0, decodeErrors[0].Index)
+}
+
+func TestFetchListingsAndDelists(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+
+	// Create client
+	twicketsClient, err := twigots.NewClient(testAPIKey)
+	require.NoError(t, err)
+
+	// Setup mock
+	url, responder := getMockUrlAndResponder(t, testEvents[:10], testTime, time.Minute)
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	httpmock.RegisterResponder("GET", url, responder)
+
+	listings, delists, err := twicketsClient.FetchTicketListingsAndDelists(
+		context.Background(),
+		twigots.FetchTicketListingsInput{
+			Country:       twigots.CountryUnitedKingdom,
+			MaxNumber:     250,
+			CreatedAfter:  testTime.Add(-5*time.Minute - 30*time.Second),
+			CreatedBefore: testTime,
+		},
+	)
+	require.NoError(t, err)
+	require.Len(t, listings, 5)
+
+	// Only delists after the created after time should be returned
+	require.Len(t, delists, 2)
+	require.Equal(t, "delisted-"+listings[1].Id, delists[0].Id)
+	require.Equal(t, testTime.Add(-2*time.Minute), delists[0].DelistedAt)
+	require.Equal(t, "delisted-"+listings[3].Id, delists[1].Id)
+	require.Equal(t, testTime.Add(-4*time.Minute), delists[1].DelistedAt)
+}
+
 // getMockUrlAndResponder returns a mock url and responder for testing purposes.
 // The responder returns events spaced at the specified interval backwards from startTime.
 func getMockUrlAndResponder(
@@ -270,7 +463,12 @@ func getMockResponse(events []string, startTime time.Time, interval time.Duratio
 
 		// Add a delisted listing after every second listing for testing
 		if (i+1)%2 == 0 {
-			delistedListing := map[string]any{"catalogBlockSummary": nil}
+			delistedListing := map[string]any{
+				"catalogBlockSummary": nil,
+				"delist":              true,
+				"blockIdToDelist":     "delisted-" + idString,
+				"timestamp":           createdAtString,
+			}
 			responseListings = append(responseListings, delistedListing)
 		}
 	}
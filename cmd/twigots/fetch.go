@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+)
+
+func runFetch(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flagSet := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	flagSet.Usage = func() {
+		fmt.Fprint(flagSet.Output(), "Fetch ticket listings and print them.\n\nUsage:\n  twigots fetch [flags]\n\nFlags:\n")
+		flagSet.PrintDefaults()
+	}
+
+	country := countryFlag{twigots.CountryUnitedKingdom}
+	var regions regionsFlag
+	var maxNumber int
+	var since, until time.Duration
+	var outputFormat string
+	var parallel bool
+	var filters filterFlags
+	var client clientFlags
+
+	flagSet.Var(&country, "country", "Country to fetch listings from")
+	flagSet.Var(&regions, "region", "Region to fetch listings from. Can be repeated. Defaults to all regions")
+	flagSet.IntVar(&maxNumber, "max", 10, "Maximum number of listings to fetch. Set to -1 for no limit (requires -since)")
+	flagSet.DurationVar(&since, "since", 0, "Only fetch listings created within this duration of now e.g. 10m")
+	flagSet.DurationVar(&until, "until", 0, "Only fetch listings created at least this duration before now e.g. 5m")
+	flagSet.BoolVar(&parallel, "parallel", false,
+		"Fetch each region concurrently under the recommended rate limit, reporting failed regions without failing")
+	flagSet.StringVar(&outputFormat, "output", string(outputFormatTable), "Output format. One of: table, json, csv")
+	filters.register(flagSet)
+	client.register(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flagSet.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %v", flagSet.Args())
+	}
+
+	writeListings, err := listingsWriter(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	predicates, err := filters.predicates(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	twicketsClient, err := client.newClient(logger)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var listings twigots.TicketListings
+	if parallel {
+		listings, err = twicketsClient.FetchTicketListingsFanOut(ctx, twigots.FetchTicketListingsFanOutInput{
+			Countries:     []twigots.Country{country.Country},
+			Regions:       regions,
+			MaxNumber:     maxNumber,
+			CreatedAfter:  relativeTime(now, since),
+			CreatedBefore: relativeTime(now, until),
+		})
+
+		// Report regions that failed, but still output listings from those that did not
+		var fanOutErr *twigots.FanOutError
+		if errors.As(err, &fanOutErr) && len(listings) != 0 {
+			fmt.Fprintf(stderr, "warning: %s\n", fanOutErr)
+			err = nil
+		}
+	} else {
+		listings, err = twicketsClient.FetchTicketListings(ctx, twigots.FetchTicketListingsInput{
+			Country:       country.Country,
+			Regions:       regions,
+			MaxNumber:     maxNumber,
+			CreatedAfter:  relativeTime(now, since),
+			CreatedBefore: relativeTime(now, until),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	listings = filter.FilterTicketListings(listings, predicates...)
+	return writeListings(stdout, listings)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+	"github.com/joho/godotenv"
+)
+
+const (
+	apiKeyEnvVar   = "TWICKETS_API_KEY"  // nolint: gosec
+	tokenEnvVar    = "TWIGOTS_API_TOKEN" // nolint: gosec
+	defaultEnvFile = ".env"
+)
+
+// regionsFlag is a flag that can be set multiple times to specify multiple regions.
+type regionsFlag []twigots.Region
+
+func (f *regionsFlag) String() string {
+	regions := make([]string, 0, len(*f))
+	for _, region := range *f {
+		regions = append(regions, region.Value)
+	}
+	return strings.Join(regions, ",")
+}
+
+func (f *regionsFlag) Set(value string) error {
+	var region twigots.Region
+	err := region.UnmarshalText([]byte(value))
+	if err != nil {
+		return err
+	}
+	*f = append(*f, region)
+	return nil
+}
+
+// countryFlag is a flag that specifies a country.
+type countryFlag struct{ twigots.Country }
+
+func (f *countryFlag) String() string {
+	return f.Value
+}
+
+func (f *countryFlag) Set(value string) error {
+	return f.UnmarshalText([]byte(value))
+}
+
+// filterFlags are flags used to filter ticket listings.
+type filterFlags struct {
+	eventName     string
+	similarity    float64
+	numTickets    int
+	maxPrice      filter.Money
+	minDiscount   float64
+	exchangeRates string
+}
+
+func (f *filterFlags) register(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&f.eventName, "event", "", "Only include listings for events matching this name")
+	flagSet.Float64Var(
+		&f.similarity, "similarity", filter.DefaultEventNameSimilarity,
+		"Minimum event name similarity (0-1) when filtering by event name",
+	)
+	flagSet.IntVar(&f.numTickets, "num-tickets", 0, "Only include listings with exactly this number of tickets")
+	flagSet.Var(
+		&f.maxPrice, "max-price",
+		"Only include listings with a ticket price (incl fee) at or below this e.g. 80 or £80. "+
+			"If a currency is given, only listings in that currency are included, unless -exchange-rates is set",
+	)
+	flagSet.Float64Var(&f.minDiscount, "min-discount", 0, "Only include listings with a discount (0-1) at or above this")
+	flagSet.StringVar(
+		&f.exchangeRates, "exchange-rates", "",
+		"Path or url of JSON exchange rates used to convert listing prices to the currency of -max-price",
+	)
+}
+
+func (f *filterFlags) predicates(ctx context.Context) ([]filter.TicketListingPredicate, error) {
+	var converter twigots.Converter
+	if f.exchangeRates != "" {
+		rates, err := twigots.LoadRates(ctx, f.exchangeRates)
+		if err != nil {
+			return nil, err
+		}
+		converter = rates
+	}
+
+	return []filter.TicketListingPredicate{
+		filter.EventName(f.eventName, f.similarity),
+		filter.NumTickets(f.numTickets),
+		filter.MaxTicketPriceInclFeeInCurrency(f.maxPrice.Price, converter),
+		filter.MinDiscount(f.minDiscount),
+	}, nil
+}
+
+// clientFlags are flags used to create a twickets client.
+type clientFlags struct {
+	envFile string
+}
+
+func (f *clientFlags) register(flagSet *flag.FlagSet) {
+	flagSet.StringVar(
+		&f.envFile, "env-file", defaultEnvFile,
+		"Path of a .env file to load environment variables (e.g. "+apiKeyEnvVar+") from if it exists",
+	)
+}
+
+// newClient creates a twickets client using the api key from the environment.
+// Feed entries that fail to decode are skipped and logged, rather than failing the whole fetch.
+func (f *clientFlags) newClient(logger *slog.Logger) (*twigots.Client, error) {
+	if f.envFile != "" {
+		// Env file is optional, so ignore any errors
+		_ = godotenv.Load(f.envFile)
+	}
+
+	apiKey := os.Getenv(apiKeyEnvVar)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%s is not set", apiKeyEnvVar)
+	}
+
+	return twigots.NewClient(
+		apiKey,
+		twigots.WithLogger(logger),
+		twigots.WithLenientDecoding(nil),
+	)
+}
+
+// relativeTime gets the time a duration ago, or zero time if the duration is zero.
+func relativeTime(now time.Time, ago time.Duration) time.Time {
+	if ago == 0 {
+		return time.Time{}
+	}
+	return now.Add(-ago)
+}
+
+// isURL returns whether a path is a http(s) url.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
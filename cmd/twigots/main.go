@@ -0,0 +1,72 @@
+// Command twigots fetches, watches and serves ticket listings from the Twickets Live Feed.
+//
+// Usage:
+//
+//	twigots <command> [flags]
+//
+// Run `twigots <command> -h` to see the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `twigots fetches and watches ticket listings from the Twickets Live Feed.
+
+Usage:
+  twigots <command> [flags]
+
+Commands:
+  fetch    Fetch ticket listings and print them
+  watch    Watch for new ticket listings and send notifications of them
+  serve    Serve an HTTP API exposing ticket listings, events and saved watches
+
+Run 'twigots <command> -h' to see the flags of a command.
+
+The Twickets API key is read from the TWICKETS_API_KEY environment variable,
+which can also be set in a .env file.
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errors.New("no command specified")
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "fetch":
+		return runFetch(ctx, args, stdout, stderr)
+	case "watch":
+		return runWatch(ctx, args, stdout, stderr)
+	case "serve":
+		return runServe(ctx, args, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command '%s'", command)
+	}
+}
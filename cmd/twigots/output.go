@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+type outputFormat string
+
+const (
+	outputFormatTable outputFormat = "table"
+	outputFormatJSON  outputFormat = "json" // Newline delimited JSON
+	outputFormatCSV   outputFormat = "csv"
+
+	outputTimeLayout = "2006-01-02 15:04"
+)
+
+// listingsWriterFunc writes ticket listings to a writer.
+type listingsWriterFunc func(io.Writer, []twigots.TicketListing) error
+
+// listingsWriter gets the function used to write listings in the specified format.
+func listingsWriter(format string) (listingsWriterFunc, error) {
+	switch outputFormat(format) {
+	case outputFormatTable:
+		return writeListingsTable, nil
+	case outputFormatJSON:
+		return writeListingsJSON, nil
+	case outputFormatCSV:
+		return writeListingsCSV, nil
+	default:
+		return nil, fmt.Errorf("output format '%s' is not valid", format)
+	}
+}
+
+var listingColumns = []string{
+	"Created", "Event", "Venue", "Date", "Tickets", "Ticket Price", "Total Price", "Original Price", "Discount", "URL",
+}
+
+func listingRow(listing twigots.TicketListing) []string {
+	return []string{
+		listing.CreatedAt.Local().Format(outputTimeLayout),
+		listing.Event.Name,
+		listing.Event.Venue.Name,
+		listing.Event.Date.Format(time.DateOnly),
+		strconv.Itoa(listing.NumTickets),
+		listing.TicketPriceInclFee().String(),
+		listing.TotalPriceInclFee().String(),
+		listing.OriginalTicketPrice().String(),
+		listing.DiscountString(),
+		listing.URL(),
+	}
+}
+
+// writeListingsTable writes listings as an aligned table.
+func writeListingsTable(writer io.Writer, listings []twigots.TicketListing) error {
+	tableWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	writeTableRow(tableWriter, listingColumns)
+	for _, listing := range listings {
+		writeTableRow(tableWriter, listingRow(listing))
+	}
+
+	return tableWriter.Flush()
+}
+
+func writeTableRow(writer io.Writer, row []string) {
+	for i, cell := range row {
+		if i != 0 {
+			fmt.Fprint(writer, "\t")
+		}
+		fmt.Fprint(writer, cell)
+	}
+	fmt.Fprintln(writer)
+}
+
+// writeListingsJSON writes listings as newline delimited JSON, one listing per line.
+func writeListingsJSON(writer io.Writer, listings []twigots.TicketListing) error {
+	encoder := json.NewEncoder(writer)
+	for _, listing := range listings {
+		err := encoder.Encode(listing)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeListingsCSV writes listings as CSV, with a header row.
+func writeListingsCSV(writer io.Writer, listings []twigots.TicketListing) error {
+	csvWriter := csv.NewWriter(writer)
+
+	err := csvWriter.Write(append([]string{"Id"}, listingColumns...))
+	if err != nil {
+		return err
+	}
+	for _, listing := range listings {
+		err := csvWriter.Write(append([]string{listing.Id}, listingRow(listing)...))
+		if err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/utilopia/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteListingsTable(t *testing.T) {
+	listings := testTicketListings(t)
+
+	var buffer bytes.Buffer
+	err := writeListingsTable(&buffer, listings)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 5)
+	require.True(t, strings.HasPrefix(lines[0], "Created"))
+	require.Contains(t, lines[1], "Foo Fighters")
+	require.Contains(t, lines[1], "£72.75")
+	require.Contains(t, lines[1], "14.41%")
+
+	// Columns should be aligned
+	eventColumn := strings.Index(lines[0], "Event")
+	for _, line := range lines[1:] {
+		require.NotEqual(t, byte(' '), line[eventColumn])
+		require.Equal(t, byte(' '), line[eventColumn-1])
+	}
+}
+
+func TestWriteListingsJSON(t *testing.T) {
+	listings := testTicketListings(t)
+
+	var buffer bytes.Buffer
+	err := writeListingsJSON(&buffer, listings)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 4)
+
+	var listing twigots.TicketListing
+	err = json.Unmarshal([]byte(lines[0]), &listing)
+	require.NoError(t, err)
+	require.Equal(t, listings[0].Id, listing.Id)
+	require.Equal(t, listings[0].Event.Name, listing.Event.Name)
+}
+
+func TestWriteListingsCSV(t *testing.T) {
+	listings := testTicketListings(t)
+
+	var buffer bytes.Buffer
+	err := writeListingsCSV(&buffer, listings)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buffer).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	require.Equal(t, "Id", records[0][0])
+	require.Equal(t, listings[0].Id, records[1][0])
+	require.Equal(t, "Foo Fighters", records[1][2])
+}
+
+func TestRunFetchInvalidFlags(t *testing.T) {
+	t.Setenv(apiKeyEnvVar, "")
+
+	err := run(context.Background(), []string{"fetch", "-output", "xml"}, io.Discard, io.Discard)
+	require.ErrorContains(t, err, "output format 'xml' is not valid")
+
+	err = run(context.Background(), []string{"fetch", "-region", "GBXX"}, io.Discard, io.Discard)
+	require.ErrorContains(t, err, "region 'GBXX' is not valid")
+
+	err = run(context.Background(), []string{"fetch", "-env-file", ""}, io.Discard, io.Discard)
+	require.ErrorContains(t, err, apiKeyEnvVar+" is not set")
+
+	err = run(context.Background(), []string{"unknown"}, io.Discard, io.Discard)
+	require.ErrorContains(t, err, "unknown command")
+}
+
+func testTicketListings(t *testing.T) twigots.TicketListings {
+	feedJson, err := os.ReadFile(testutils.ProjectDirectoryJoin(t, "test", "data", "fullFeedResponse.json"))
+	require.NoError(t, err)
+
+	listings, err := twigots.UnmarshalTwicketsFeedJson(feedJson)
+	require.NoError(t, err)
+
+	return listings
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/rules"
+	"github.com/ahobsonsayers/twigots/server"
+	"github.com/ahobsonsayers/twigots/store"
+	"github.com/ahobsonsayers/twigots/watch"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func runServe(ctx context.Context, args []string, _, stderr io.Writer) error {
+	flagSet := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	flagSet.Usage = func() {
+		fmt.Fprint(
+			flagSet.Output(),
+			"Serve an HTTP API exposing ticket listings, events and saved watches.\n"+
+				"The OpenAPI document of the API is served at /openapi.json.\n"+
+				"Watch endpoints are disabled unless "+tokenEnvVar+" is set, and requests to them must\n"+
+				"send it as a bearer token.\n\n"+
+				"Usage:\n  twigots serve [flags]\n\nFlags:\n",
+		)
+		flagSet.PrintDefaults()
+	}
+
+	country := countryFlag{twigots.CountryUnitedKingdom}
+	var regions regionsFlag
+	var address, storeDirectory, watchesFile, rulesStateFile string
+	var pollInterval time.Duration
+	var client clientFlags
+
+	flagSet.StringVar(
+		&address, "addr", "127.0.0.1:8080",
+		"Address to listen on. Only watch endpoints are authenticated (see "+tokenEnvVar+
+			"), so the API is only served locally by default",
+	)
+	flagSet.StringVar(&storeDirectory, "store-dir", "twigots-store", "Directory listings are stored in")
+	flagSet.StringVar(&watchesFile, "watches", "twigots-watches.json", "Path of the file saved watches are stored in")
+	flagSet.StringVar(
+		&rulesStateFile, "rules-state", defaultRulesStateFile,
+		"Path of the file watch rule state is stored in",
+	)
+	flagSet.DurationVar(
+		&pollInterval, "poll-interval", watch.DefaultPollInterval,
+		"Interval between polls for new listings, which are stored and evaluated against saved watches. "+
+			"Set to 0 to disable polling",
+	)
+	flagSet.Var(&country, "country", "Country to poll listings from")
+	flagSet.Var(&regions, "region", "Region to poll listings from. Can be repeated. Defaults to all regions")
+	client.register(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flagSet.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %v", flagSet.Args())
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+	twicketsClient, err := client.newClient(logger)
+	if err != nil {
+		return err
+	}
+
+	listingStore, err := store.NewFileStore(storeDirectory)
+	if err != nil {
+		return err
+	}
+	defer listingStore.Close()
+
+	watches, err := server.NewWatches(watchesFile)
+	if err != nil {
+		return err
+	}
+
+	rulesState, err := rules.LoadState(rulesStateFile)
+	if err != nil {
+		return err
+	}
+
+	// Watches can send alerts to any url, so they are only enabled if a token is set
+	token := os.Getenv(tokenEnvVar)
+	if token == "" {
+		logger.Warn("Watch endpoints are disabled as " + tokenEnvVar + " is not set")
+	}
+
+	apiServer := server.New(twicketsClient, listingStore, watches, rulesState, server.WithToken(token))
+
+	if pollInterval > 0 {
+		go pollForServer(ctx, logger, apiServer, twicketsClient, twigots.FetchTicketListingsInput{
+			Country: country.Country,
+			Regions: regions,
+		}, pollInterval, rulesStateFile)
+	}
+
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           apiServer,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("Serving API", "address", address)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Stopping server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	return apiServer.SaveRulesState(rulesStateFile)
+}
+
+// pollForServer polls for new listings and delists until the context is cancelled, passing them to the server
+// to be stored, evaluated against saved watches and streamed. Pending watch alerts are also flushed every poll.
+func pollForServer(
+	ctx context.Context,
+	logger *slog.Logger,
+	apiServer *server.Server,
+	fetcher watch.Fetcher,
+	input twigots.FetchTicketListingsInput,
+	interval time.Duration,
+	rulesStateFile string,
+) {
+	poller := &watch.Poller{
+		Fetcher:  fetcher,
+		Input:    input,
+		Interval: interval,
+		HandleDelists: func(ctx context.Context, delists []twigots.Delist) error {
+			logger.Info("Found delisted listings", "delisted", len(delists))
+			var errs []error
+			for _, delist := range delists {
+				delistedAt := delist.DelistedAt
+				if delistedAt.IsZero() {
+					delistedAt = time.Now()
+				}
+				err := apiServer.Delist(ctx, delist.Id, delistedAt)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to delist listing '%s': %w", delist.Id, err))
+				}
+			}
+			return errors.Join(errs...)
+		},
+		OnError: func(err error) {
+			logger.Error("Failed to poll for listings", "error", err)
+		},
+	}
+
+	handle := func(ctx context.Context, listings twigots.TicketListings) error {
+		logger.Info("Found new listings", "new", len(listings))
+		processErr := apiServer.Process(ctx, time.Now(), listings...)
+		saveErr := apiServer.SaveRulesState(rulesStateFile)
+		return errors.Join(processErr, saveErr)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := apiServer.Flush(ctx)
+				if err != nil {
+					logger.Error("Failed to send pending watch alerts", "error", err)
+				}
+			}
+		}
+	}()
+
+	_ = poller.Run(ctx, handle)
+}
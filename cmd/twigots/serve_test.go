@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/server"
+	"github.com/ahobsonsayers/twigots/store"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollForServerStreamsDelists(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Feed containing an old listing, and a listing delisted after polling starts
+	delistedAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	feedJson := fmt.Sprintf(`{"responseData": [
+		{
+			"catalogBlockSummary": {"blockId": "1", "created": "1717200000000", "event": {"id": "1"}},
+			"delist": false,
+			"blockIdToDelist": null,
+			"timestamp": "1717200000000"
+		},
+		{
+			"catalogBlockSummary": null,
+			"delist": true,
+			"blockIdToDelist": "2",
+			"timestamp": "%d"
+		}
+	]}`, delistedAt.UnixMilli())
+
+	twicketsClient, err := twigots.NewClient("test")
+	require.NoError(t, err)
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	t.Cleanup(httpmock.DeactivateAndReset)
+	httpmock.RegisterRegexpResponder(
+		http.MethodGet,
+		regexp.MustCompile(`^https://www\.twickets\.live/services/catalogue`),
+		httpmock.NewStringResponder(http.StatusOK, feedJson),
+	)
+
+	listingStore, err := store.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listingStore.Close() })
+	watches, err := server.NewWatches(filepath.Join(t.TempDir(), "watches.json"))
+	require.NoError(t, err)
+	apiServer := server.New(twicketsClient, listingStore, watches, nil)
+
+	httpServer := httptest.NewServer(apiServer)
+	t.Cleanup(httpServer.Close)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/stream", http.NoBody)
+	require.NoError(t, err)
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	input := twigots.FetchTicketListingsInput{Country: twigots.CountryUnitedKingdom}
+	rulesStateFile := filepath.Join(t.TempDir(), "rules-state.json")
+	go pollForServer(ctx, logger, apiServer, twicketsClient, input, 10*time.Millisecond, rulesStateFile)
+
+	// The delist in the feed should be streamed
+	var eventType string
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			eventType = strings.TrimPrefix(line, "event: ")
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		require.Equal(t, "delist", eventType)
+		var event server.StreamEvent
+		err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event)
+		require.NoError(t, err)
+		require.Equal(t, server.StreamEventTypeDelist, event.Type)
+		require.Equal(t, "2", event.ListingId)
+		require.True(t, delistedAt.Equal(event.At))
+		return
+	}
+	t.Fatalf("stream ended before a delist was received: %v", scanner.Err())
+}
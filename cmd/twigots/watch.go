@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+	"github.com/ahobsonsayers/twigots/notify"
+	"github.com/ahobsonsayers/twigots/rules"
+	"github.com/ahobsonsayers/twigots/watch"
+)
+
+const (
+	defaultStateFile      = "twigots-state.json"
+	defaultRulesStateFile = "twigots-rules-state.json"
+
+	// Interval between flushes of pending rule alerts
+	rulesFlushInterval = time.Minute
+
+	// Interval between refreshes of exchange rates
+	exchangeRatesRefreshInterval = time.Hour
+)
+
+// watchConfig is the configuration file of the watch command.
+type watchConfig struct {
+	// Country to watch listings in. Defaults to GB.
+	Country twigots.Country `json:"country"`
+
+	// Regions to watch listings in. Defaults to all regions.
+	Regions []twigots.Region `json:"regions,omitempty"`
+
+	// Interval between polls e.g. "1m". Defaults to 1 minute.
+	Interval duration `json:"interval,omitempty"`
+
+	// Filters listings must match to be notified about. A listing only needs to match one filter.
+	// Defaults to matching all listings.
+	Filters []filter.Config `json:"filters,omitempty"`
+
+	// Sinks to send notifications of matching listings to.
+	// Defaults to stdout, unless rules are configured.
+	Sinks []notify.SinkConfig `json:"sinks,omitempty"`
+
+	// Rules to evaluate against new listings. These are evaluated in addition to filters and sinks.
+	// See the rules package for details.
+	Rules []rules.Rule `json:"rules,omitempty"`
+
+	// StateFile is the path of the file used to persist state between runs.
+	// Relative paths are relative to the config file. Defaults to twigots-state.json.
+	StateFile string `json:"stateFile,omitempty"`
+
+	// RulesStateFile is the path of the file used to persist rule state (cooldowns, throttling etc.) between runs.
+	// Relative paths are relative to the config file. Defaults to twigots-rules-state.json.
+	RulesStateFile string `json:"rulesStateFile,omitempty"`
+
+	// ExchangeRates is the path of a JSON exchange rates file, or a url to fetch them from.
+	// These are used to convert listing prices to the currency of max ticket prices of filters and rules.
+	// Relative paths are relative to the config file. Rates are refreshed hourly.
+	// See twigots.Rates for the format.
+	ExchangeRates string `json:"exchangeRates,omitempty"`
+}
+
+func loadWatchConfig(path string) (watchConfig, error) {
+	configJson, err := os.ReadFile(path)
+	if err != nil {
+		return watchConfig{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config watchConfig
+	err = json.Unmarshal(configJson, &config)
+	if err != nil {
+		return watchConfig{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	if config.Country.Value == "" {
+		config.Country = twigots.CountryUnitedKingdom
+	}
+	if config.StateFile == "" {
+		config.StateFile = defaultStateFile
+	}
+	if !filepath.IsAbs(config.StateFile) {
+		config.StateFile = filepath.Join(filepath.Dir(path), config.StateFile)
+	}
+	if config.RulesStateFile == "" {
+		config.RulesStateFile = defaultRulesStateFile
+	}
+	if !filepath.IsAbs(config.RulesStateFile) {
+		config.RulesStateFile = filepath.Join(filepath.Dir(path), config.RulesStateFile)
+	}
+	if config.ExchangeRates != "" && !isURL(config.ExchangeRates) && !filepath.IsAbs(config.ExchangeRates) {
+		config.ExchangeRates = filepath.Join(filepath.Dir(path), config.ExchangeRates)
+	}
+
+	return config, nil
+}
+
+// setConverter sets the converter used by all configured filters and rules.
+func (c watchConfig) setConverter(converter twigots.Converter) {
+	for idx := range c.Filters {
+		c.Filters[idx].Converter = converter
+	}
+	for idx := range c.Rules {
+		c.Rules[idx].Filter.Converter = converter
+	}
+}
+
+// predicate creates a predicate that matches listings matching any of the configured filters.
+func (c watchConfig) predicate() filter.TicketListingPredicate {
+	if len(c.Filters) == 0 {
+		return func(twigots.TicketListing) bool { return true }
+	}
+
+	predicates := make([]filter.TicketListingPredicate, 0, len(c.Filters))
+	for _, filterConfig := range c.Filters {
+		predicates = append(predicates, filterConfig.Predicate())
+	}
+	return func(listing twigots.TicketListing) bool {
+		return filter.TicketListingMatchesAnyPredicate(listing, predicates...)
+	}
+}
+
+// watchState is the state of the watch command persisted between runs.
+type watchState struct {
+	// HighWaterMark is the creation time of the newest listing seen.
+	HighWaterMark time.Time `json:"highWaterMark"`
+}
+
+func loadWatchState(path string) (watchState, error) {
+	stateJson, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return watchState{}, nil
+	}
+	if err != nil {
+		return watchState{}, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var state watchState
+	err = json.Unmarshal(stateJson, &state)
+	if err != nil {
+		return watchState{}, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	return state, nil
+}
+
+// save the state, writing to a temporary file first so state is never partially written.
+func (s watchState) save(path string) error {
+	stateJson, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	temporaryPath := path + ".tmp"
+	err = os.WriteFile(temporaryPath, stateJson, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	err = os.Rename(temporaryPath, path)
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return nil
+}
+
+func runWatch(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flagSet := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flagSet.SetOutput(stderr)
+	flagSet.Usage = func() {
+		fmt.Fprint(
+			flagSet.Output(),
+			"Watch for new ticket listings, sending notifications of those matching filters to sinks.\n\n"+
+				"Usage:\n  twigots watch -config <path> [flags]\n\nFlags:\n",
+		)
+		flagSet.PrintDefaults()
+	}
+
+	var configPath string
+	var client clientFlags
+	flagSet.StringVar(&configPath, "config", "", "Path of the JSON watch config file. Required")
+	client.register(flagSet)
+
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flagSet.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %v", flagSet.Args())
+	}
+	if configPath == "" {
+		return errors.New("config must be set")
+	}
+
+	config, err := loadWatchConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+	if config.ExchangeRates != "" {
+		converter, err := twigots.NewRefreshingConverter(ctx, config.ExchangeRates)
+		if err != nil {
+			return err
+		}
+		config.setConverter(converter)
+
+		go func() {
+			_ = converter.Run(ctx, exchangeRatesRefreshInterval, func(err error) {
+				logger.Error("Failed to refresh exchange rates", "error", err)
+			})
+		}()
+	}
+
+	sink, err := watchSink(config, stdout)
+	if err != nil {
+		return err
+	}
+
+	state, err := loadWatchState(config.StateFile)
+	if err != nil {
+		return err
+	}
+
+	rulesEngine, err := watchRulesEngine(config)
+	if err != nil {
+		return err
+	}
+
+	twicketsClient, err := client.newClient(logger)
+	if err != nil {
+		return err
+	}
+
+	poller := &watch.Poller{
+		Fetcher: twicketsClient,
+		Input: twigots.FetchTicketListingsInput{
+			Country: config.Country,
+			Regions: config.Regions,
+		},
+		Interval: time.Duration(config.Interval),
+		OnError: func(err error) {
+			logger.Error("Failed to watch for listings", "error", err)
+		},
+	}
+	poller.SetHighWaterMark(state.HighWaterMark)
+
+	if rulesEngine != nil {
+		go func() {
+			_ = rulesEngine.Run(ctx, rulesFlushInterval, func(err error) {
+				logger.Error("Failed to send pending rule alerts", "error", err)
+			})
+		}()
+	}
+
+	predicate := config.predicate()
+	logger.Info("Watching for listings", "country", config.Country.Value, "interval", poller.Interval)
+	err = poller.Run(ctx, func(ctx context.Context, listings twigots.TicketListings) error {
+		var errs []error
+		if sink != nil {
+			matchingListings := filter.FilterTicketListings(listings, predicate)
+			logger.Info("Found new listings", "new", len(listings), "matching", len(matchingListings))
+
+			// Save state even if sending fails, to avoid repeatedly sending the same notifications
+			errs = append(errs, sink.Send(ctx, matchingListings...))
+		} else {
+			logger.Info("Found new listings", "new", len(listings))
+		}
+
+		if rulesEngine != nil {
+			errs = append(errs, rulesEngine.Process(ctx, listings...))
+			errs = append(errs, rulesEngine.SaveState(config.RulesStateFile))
+		}
+
+		errs = append(errs, watchState{HighWaterMark: poller.HighWaterMark()}.save(config.StateFile))
+		return errors.Join(errs...)
+	})
+	if errors.Is(err, context.Canceled) {
+		logger.Info("Stopped watching for listings")
+
+		// Save state on shutdown, as the first poll sets the high-water mark without finding any listings
+		var rulesSaveErr error
+		if rulesEngine != nil {
+			rulesSaveErr = rulesEngine.SaveState(config.RulesStateFile)
+		}
+		return errors.Join(
+			watchState{HighWaterMark: poller.HighWaterMark()}.save(config.StateFile),
+			rulesSaveErr,
+		)
+	}
+	return err
+}
+
+// watchSink creates the sink notifications are sent to. Defaults to printing to stdout if no sinks are configured.
+// Returns nil if no sinks are configured, but rules are.
+func watchSink(config watchConfig, stdout io.Writer) (notify.Sink, error) {
+	if len(config.Sinks) == 0 {
+		if len(config.Rules) != 0 {
+			return nil, nil
+		}
+		return notify.NewTextSink(stdout), nil
+	}
+
+	sinks, err := notify.Sinks(config.Sinks...)
+	if err != nil {
+		return nil, err
+	}
+	return notify.MultiSink(sinks...), nil
+}
+
+// watchRulesEngine creates the engine evaluating the configured rules, loading any persisted rule state.
+// Returns nil if no rules are configured.
+func watchRulesEngine(config watchConfig) (*rules.Engine, error) {
+	if len(config.Rules) == 0 {
+		return nil, nil
+	}
+
+	state, err := rules.LoadState(config.RulesStateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules.NewEngine(config.Rules, state)
+}
+
+// duration is a time.Duration that is unmarshalled from a string such as "1m30s".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var durationString string
+	err := json.Unmarshal(data, &durationString)
+	if err != nil {
+		return err
+	}
+
+	parsedDuration, err := time.ParseDuration(durationString)
+	if err != nil {
+		return err
+	}
+
+	*d = duration(parsedDuration)
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
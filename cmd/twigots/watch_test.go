@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/notify"
+	"github.com/ahobsonsayers/twigots/rules"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWatchConfig(t *testing.T) {
+	directory := t.TempDir()
+	configPath := filepath.Join(directory, "config.json")
+	err := os.WriteFile(configPath, []byte(`{
+		"regions": ["GBLO"],
+		"interval": "30s",
+		"filters": [
+			{"event": "Coldplay", "numTickets": 2},
+			{"event": "Hamilton", "maxTicketPrice": 50}
+		],
+		"sinks": [{"type": "file", "path": "listings.jsonl"}]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	config, err := loadWatchConfig(configPath)
+	require.NoError(t, err)
+	require.Equal(t, twigots.CountryUnitedKingdom, config.Country)
+	require.Equal(t, []twigots.Region{twigots.RegionLondon}, config.Regions)
+	require.Equal(t, 30*time.Second, time.Duration(config.Interval))
+	require.Equal(t, filepath.Join(directory, defaultStateFile), config.StateFile)
+	require.Len(t, config.Filters, 2)
+	require.Equal(t, []notify.SinkConfig{{Type: notify.SinkTypeFile, Path: "listings.jsonl"}}, config.Sinks)
+
+	// Listings should match if they match any filter
+	predicate := config.predicate()
+	coldplayListing := twigots.TicketListing{NumTickets: 2, Event: twigots.Event{Name: "Coldplay"}}
+	require.True(t, predicate(coldplayListing))
+	coldplayListing.NumTickets = 3
+	require.False(t, predicate(coldplayListing))
+	hamiltonListing := twigots.TicketListing{NumTickets: 3, Event: twigots.Event{Name: "Hamilton"}}
+	require.True(t, predicate(hamiltonListing))
+}
+
+func TestWatchState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// Missing state should be empty
+	state, err := loadWatchState(path)
+	require.NoError(t, err)
+	require.True(t, state.HighWaterMark.IsZero())
+
+	highWaterMark := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	err = watchState{HighWaterMark: highWaterMark}.save(path)
+	require.NoError(t, err)
+
+	state, err = loadWatchState(path)
+	require.NoError(t, err)
+	require.Equal(t, highWaterMark, state.HighWaterMark)
+}
+
+func TestLoadWatchConfigRules(t *testing.T) {
+	directory := t.TempDir()
+	configPath := filepath.Join(directory, "config.json")
+	err := os.WriteFile(configPath, []byte(`{
+		"rules": [{
+			"name": "coldplay",
+			"filter": {"event": "Coldplay"},
+			"sinks": [{"type": "file", "path": "listings.jsonl"}],
+			"cooldown": "1h",
+			"quietHours": {"start": "22:00", "end": "07:00", "timeZone": "Europe/London"},
+			"maxAlertsPerHour": 5
+		}]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	config, err := loadWatchConfig(configPath)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(directory, defaultRulesStateFile), config.RulesStateFile)
+	require.Len(t, config.Rules, 1)
+	require.Equal(t, rules.Duration(time.Hour), config.Rules[0].Cooldown)
+
+	// No default sink should be used if only rules are configured
+	sink, err := watchSink(config, os.Stdout)
+	require.NoError(t, err)
+	require.Nil(t, sink)
+
+	engine, err := watchRulesEngine(config)
+	require.NoError(t, err)
+	require.NotNil(t, engine)
+}
@@ -0,0 +1,251 @@
+package twigots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Converter converts prices between currencies.
+type Converter interface {
+	// Convert a price to a currency.
+	// Returns an error if either currency is not supported.
+	Convert(price Price, to Currency) (Price, error)
+}
+
+// Rates is a static table of exchange rates relative to a base currency. It implements Converter.
+//
+// In JSON, rates are given in the format used by many exchange rate APIs e.g.
+//
+//	{"base": "GBP", "rates": {"EUR": 1.17, "USD": 1.27}}
+type Rates struct {
+	// Base currency of the rates.
+	Base Currency
+
+	// Rates are the number of units of each currency equal to one unit of the base currency.
+	// The rate of the base currency is always 1, so does not need to be set.
+	Rates map[Currency]float64
+}
+
+var _ Converter = Rates{}
+
+// Convert a price to a currency.
+// Returns an error if there is no rate for either currency, or the price has no currency.
+func (r Rates) Convert(price Price, to Currency) (Price, error) {
+	if price.Currency == to {
+		return price, nil
+	}
+	if price.Currency.Value == "" {
+		return Price{}, errors.New("cannot convert price without a currency")
+	}
+
+	fromRate, err := r.rate(price.Currency)
+	if err != nil {
+		return Price{}, err
+	}
+	toRate, err := r.rate(to)
+	if err != nil {
+		return Price{}, err
+	}
+
+	value := price.Number() / fromRate * toRate
+	amount := math.Round(value * math.Pow10(to.Exponent()))
+	if amount > math.MaxInt64 || amount < math.MinInt64 {
+		return Price{}, fmt.Errorf("%w: converting %s to %s", ErrPriceOverflow, price, to.Value)
+	}
+
+	return Price{Currency: to, Amount: int64(amount)}, nil
+}
+
+func (r Rates) rate(currency Currency) (float64, error) {
+	if currency == r.Base {
+		return 1, nil
+	}
+	rate, ok := r.Rates[currency]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for currency '%s'", currency.Value)
+	}
+	return rate, nil
+}
+
+// Validate the rates.
+func (r Rates) Validate() error {
+	if r.Base.Value == "" {
+		return errors.New("base currency must be set")
+	}
+	for currency, rate := range r.Rates {
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return fmt.Errorf("exchange rate for currency '%s' must be positive", currency.Value)
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON unmarshals rates, keeping unknown currency codes rather than failing.
+func (r *Rates) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}{}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	r.Base = Currency{aux.Base}
+	r.Rates = make(map[Currency]float64, len(aux.Rates))
+	for currencyCode, rate := range aux.Rates {
+		r.Rates[Currency{currencyCode}] = rate
+	}
+	return nil
+}
+
+func (r Rates) MarshalJSON() ([]byte, error) {
+	rates := make(map[string]float64, len(r.Rates))
+	for currency, rate := range r.Rates {
+		rates[currency.Value] = rate
+	}
+	return json.Marshal(struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}{
+		Base:  r.Base.Value,
+		Rates: rates,
+	})
+}
+
+// LoadRates loads exchange rates from a JSON file, or from a JSON url if source is a http(s) url.
+// See Rates for the format.
+func LoadRates(ctx context.Context, source string) (Rates, error) {
+	if isHTTPURL(source) {
+		return FetchRates(ctx, source)
+	}
+
+	ratesJson, err := os.ReadFile(source)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to read exchange rates: %w", err)
+	}
+	return parseRates(ratesJson)
+}
+
+// RatesTimeout is the timeout of requests made to fetch exchange rates.
+const RatesTimeout = 30 * time.Second
+
+// ratesClient is the http client used to fetch exchange rates,
+// so a stalled rates server cannot block a refresh forever.
+var ratesClient = &http.Client{Timeout: RatesTimeout}
+
+// FetchRates fetches exchange rates from a JSON url. See Rates for the format.
+// The request times out after RatesTimeout, if ctx is not done before then.
+func FetchRates(ctx context.Context, url string) (Rates, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to create exchange rates request: %w", err)
+	}
+
+	response, err := ratesClient.Do(request)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to fetch exchange rates: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Rates{}, fmt.Errorf("failed to fetch exchange rates: %s", response.Status)
+	}
+
+	ratesJson, err := io.ReadAll(response.Body)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to read exchange rates: %w", err)
+	}
+	return parseRates(ratesJson)
+}
+
+func parseRates(ratesJson []byte) (Rates, error) {
+	var rates Rates
+	err := json.Unmarshal(ratesJson, &rates)
+	if err != nil {
+		return Rates{}, fmt.Errorf("failed to parse exchange rates: %w", err)
+	}
+
+	err = rates.Validate()
+	if err != nil {
+		return Rates{}, fmt.Errorf("invalid exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+func isHTTPURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// RefreshingConverter converts prices using exchange rates loaded from a JSON file or url,
+// which can be periodically reloaded to keep rates up to date. It implements Converter,
+// and is safe for concurrent use.
+type RefreshingConverter struct {
+	source string
+
+	mutex sync.RWMutex
+	rates Rates
+}
+
+var _ Converter = (*RefreshingConverter)(nil)
+
+// NewRefreshingConverter creates a converter, loading its rates from a JSON file or url. See LoadRates.
+func NewRefreshingConverter(ctx context.Context, source string) (*RefreshingConverter, error) {
+	converter := &RefreshingConverter{source: source}
+	err := converter.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return converter, nil
+}
+
+// Convert a price to a currency, using the latest rates. See Rates.Convert.
+func (c *RefreshingConverter) Convert(price Price, to Currency) (Price, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.rates.Convert(price, to)
+}
+
+// Refresh reloads the rates. If reloading fails, the previous rates are kept.
+func (c *RefreshingConverter) Refresh(ctx context.Context) error {
+	rates, err := LoadRates(ctx, c.source)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rates = rates
+	return nil
+}
+
+// Run refreshes the rates every interval until the context is cancelled.
+// Any errors refreshing are passed to onError, which can be nil.
+//
+// Returns the context error once the context is cancelled.
+func (c *RefreshingConverter) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			err := c.Refresh(ctx)
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
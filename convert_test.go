@@ -0,0 +1,130 @@
+package twigots_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+var testRates = twigots.Rates{
+	Base: twigots.CurrencyGBP,
+	Rates: map[twigots.Currency]float64{
+		twigots.CurrencyEUR: 1.25,
+		twigots.CurrencyUSD: 1.5,
+	},
+}
+
+func TestRatesConvert(t *testing.T) {
+	// Same currency
+	price, err := testRates.Convert(twigots.Price{Currency: twigots.CurrencyEUR, Amount: 1000}, twigots.CurrencyEUR)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 1000}, price)
+
+	// From base
+	price, err = testRates.Convert(twigots.Price{Currency: twigots.CurrencyGBP, Amount: 8000}, twigots.CurrencyEUR)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 10000}, price)
+
+	// To base
+	price, err = testRates.Convert(twigots.Price{Currency: twigots.CurrencyEUR, Amount: 10000}, twigots.CurrencyGBP)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyGBP, Amount: 8000}, price)
+
+	// Via base, rounded to the nearest minor unit
+	price, err = testRates.Convert(twigots.Price{Currency: twigots.CurrencyEUR, Amount: 1001}, twigots.CurrencyUSD)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyUSD, Amount: 1201}, price)
+
+	// Missing rate
+	_, err = testRates.Convert(twigots.Price{Currency: twigots.CurrencyEUR, Amount: 1000}, twigots.CurrencySEK)
+	require.Error(t, err)
+
+	// No currency
+	_, err = testRates.Convert(twigots.Price{Amount: 1000}, twigots.CurrencyGBP)
+	require.Error(t, err)
+}
+
+func TestRatesJSON(t *testing.T) {
+	ratesJson, err := json.Marshal(testRates)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"base":"GBP","rates":{"EUR":1.25,"USD":1.5}}`, string(ratesJson))
+
+	var rates twigots.Rates
+	err = json.Unmarshal(ratesJson, &rates)
+	require.NoError(t, err)
+	require.Equal(t, testRates, rates)
+}
+
+func TestRatesValidate(t *testing.T) {
+	require.NoError(t, testRates.Validate())
+	require.Error(t, twigots.Rates{}.Validate())
+	require.Error(t, twigots.Rates{
+		Base:  twigots.CurrencyGBP,
+		Rates: map[twigots.Currency]float64{twigots.CurrencyEUR: 0},
+	}.Validate())
+}
+
+func TestLoadRatesFile(t *testing.T) {
+	ratesPath := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(ratesPath, []byte(`{"base":"GBP","rates":{"EUR":1.25,"USD":1.5}}`), 0o600)
+	require.NoError(t, err)
+
+	rates, err := twigots.LoadRates(context.Background(), ratesPath)
+	require.NoError(t, err)
+	require.Equal(t, testRates, rates)
+
+	_, err = twigots.LoadRates(context.Background(), filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestLoadRatesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"base":"GBP","rates":{"EUR":1.25,"USD":1.5}}`))
+	}))
+	defer server.Close()
+
+	rates, err := twigots.LoadRates(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, testRates, rates)
+}
+
+func TestRefreshingConverter(t *testing.T) {
+	ratesPath := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(ratesPath, []byte(`{"base":"GBP","rates":{"EUR":1.25}}`), 0o600)
+	require.NoError(t, err)
+
+	converter, err := twigots.NewRefreshingConverter(context.Background(), ratesPath)
+	require.NoError(t, err)
+
+	gbp := twigots.Price{Currency: twigots.CurrencyGBP, Amount: 8000}
+	price, err := converter.Convert(gbp, twigots.CurrencyEUR)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 10000}, price)
+
+	// Refreshing should pick up new rates
+	err = os.WriteFile(ratesPath, []byte(`{"base":"GBP","rates":{"EUR":1.5}}`), 0o600)
+	require.NoError(t, err)
+	err = converter.Refresh(context.Background())
+	require.NoError(t, err)
+
+	price, err = converter.Convert(gbp, twigots.CurrencyEUR)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 12000}, price)
+
+	// Failing to refresh should keep the previous rates
+	err = os.WriteFile(ratesPath, []byte(`invalid`), 0o600)
+	require.NoError(t, err)
+	err = converter.Refresh(context.Background())
+	require.Error(t, err)
+
+	price, err = converter.Convert(gbp, twigots.CurrencyEUR)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 12000}, price)
+}
@@ -30,6 +30,10 @@ func (dt *DateTime) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (dt DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dt.UTC().Format(dateTimeLayout))
+}
+
 // Date is a date (with no time).
 type Date struct{ time.Time }
 
@@ -48,7 +52,11 @@ func (d *Date) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// Date is a time (with no date).
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(dateLayout))
+}
+
+// Time is a time (with no date).
 type Time struct{ time.Time }
 
 func (t *Time) UnmarshalJSON(data []byte) error {
@@ -66,6 +74,10 @@ func (t *Time) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Format(timeLayout))
+}
+
 // UnixTime is a time from unix time.
 type UnixTime struct{ time.Time }
 
@@ -84,3 +96,7 @@ func (t *UnixTime) UnmarshalJSON(data []byte) error {
 	t.Time = time.UnixMilli(int64(timeInt))
 	return nil
 }
+
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(t.UnixMilli(), 10))
+}
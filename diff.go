@@ -0,0 +1,93 @@
+package twigots
+
+// ListingChange is a ticket listing that has changed between two fetches.
+type ListingChange struct {
+	Old TicketListing
+	New TicketListing
+}
+
+// PriceChanged returns whether the price of a single ticket, excluding fee, has changed.
+func (c ListingChange) PriceChanged() bool {
+	return !c.Old.TicketPriceExclFee().Equal(c.New.TicketPriceExclFee())
+}
+
+// NumTicketsChanged returns whether the number of tickets has changed
+// e.g. if the seller has sold part of the listing.
+func (c ListingChange) NumTicketsChanged() bool {
+	return c.Old.NumTickets != c.New.NumTickets
+}
+
+// ExpiryChanged returns whether the time the listing expires has changed.
+func (c ListingChange) ExpiryChanged() bool {
+	return !c.Old.ExpiresAt.Equal(c.New.ExpiresAt.Time)
+}
+
+// ListingsDiff describes how ticket listings have changed between two fetches.
+type ListingsDiff struct {
+	// Added are listings that are only in the new listings.
+	Added TicketListings
+
+	// Removed are listings that are only in the old listings
+	// e.g. because they have sold, been delisted or expired.
+	Removed TicketListings
+
+	// Changed are listings in both the old and new listings whose price,
+	// number of tickets or expiry has changed.
+	Changed []ListingChange
+}
+
+// IsEmpty returns whether there are no differences.
+func (d ListingsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares two sets of ticket listings by id, reporting the listings that have been added,
+// removed or changed. Changes cover the price of a single ticket, the number of tickets and the expiry.
+//
+// Added and changed listings are in the order of the new listings,
+// and removed listings are in the order of the old listings.
+// If a listing id appears more than once, only its first occurrence is used.
+func Diff(oldListings, newListings TicketListings) ListingsDiff {
+	oldIndexes := firstIndexesById(oldListings)
+	newIndexes := firstIndexesById(newListings)
+
+	var diff ListingsDiff
+	for idx, newListing := range newListings {
+		if newIndexes[newListing.Id] != idx {
+			continue // Duplicate
+		}
+
+		oldIdx, ok := oldIndexes[newListing.Id]
+		if !ok {
+			diff.Added = append(diff.Added, newListing)
+			continue
+		}
+
+		change := ListingChange{Old: oldListings[oldIdx], New: newListing}
+		if change.PriceChanged() || change.NumTicketsChanged() || change.ExpiryChanged() {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	for idx, oldListing := range oldListings {
+		if oldIndexes[oldListing.Id] != idx {
+			continue // Duplicate
+		}
+		if _, ok := newIndexes[oldListing.Id]; !ok {
+			diff.Removed = append(diff.Removed, oldListing)
+		}
+	}
+
+	return diff
+}
+
+// firstIndexesById gets the index of the first occurrence of each listing id.
+func firstIndexesById(listings TicketListings) map[string]int {
+	indexes := make(map[string]int, len(listings))
+	for idx, listing := range listings {
+		if _, ok := indexes[listing.Id]; !ok {
+			indexes[listing.Id] = idx
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,54 @@
+package twigots_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	listings := testTicketListings(t)
+	oldListings := twigots.TicketListings{listings[0], listings[1], listings[2]}
+
+	// Foo Fighters has been re-priced
+	repriced := listings[0]
+	repriced.TotalPriceExclFee.Amount -= 300
+
+	// Mean Girls has had one of its tickets sold
+	partSold := listings[1]
+	partSold.NumTickets = 1
+	partSold.TotalPriceExclFee = partSold.TotalPriceExclFee.Divide(2)
+	partSold.TwicketsFee = partSold.TwicketsFee.Divide(2)
+
+	// South Africa v Wales has been removed, and Download Festival has been added
+	newListings := twigots.TicketListings{listings[3], repriced, partSold, repriced}
+
+	diff := twigots.Diff(oldListings, newListings)
+	require.False(t, diff.IsEmpty())
+	require.Equal(t, twigots.TicketListings{listings[3]}, diff.Added)
+	require.Equal(t, twigots.TicketListings{listings[2]}, diff.Removed)
+
+	require.Len(t, diff.Changed, 2)
+	require.Equal(t, listings[0], diff.Changed[0].Old)
+	require.Equal(t, repriced, diff.Changed[0].New)
+	require.True(t, diff.Changed[0].PriceChanged())
+	require.False(t, diff.Changed[0].NumTicketsChanged())
+	require.False(t, diff.Changed[0].ExpiryChanged())
+
+	require.Equal(t, partSold, diff.Changed[1].New)
+	require.False(t, diff.Changed[1].PriceChanged())
+	require.True(t, diff.Changed[1].NumTicketsChanged())
+
+	// Expiry changes should be reported
+	extended := listings[2]
+	extended.ExpiresAt = twigots.UnixTime{Time: extended.ExpiresAt.Add(time.Hour)}
+	diff = twigots.Diff(oldListings, twigots.TicketListings{listings[0], listings[1], extended})
+	require.Len(t, diff.Changed, 1)
+	require.True(t, diff.Changed[0].ExpiryChanged())
+
+	// Identical listings should have no differences
+	require.True(t, twigots.Diff(listings, listings).IsEmpty())
+	require.True(t, twigots.Diff(nil, nil).IsEmpty())
+}
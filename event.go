@@ -1,5 +1,10 @@
 package twigots
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Event contains the details of an event.
 type Event struct {
 	Id       string `json:"id"`
@@ -15,6 +20,26 @@ type Event struct {
 	Lineup []Lineup `json:"participants"`
 }
 
+// StartTime is the date and time the event starts.
+//
+// The start time is interpreted in the time zone of the event venue location.
+// If the time zone is unknown, UTC is used.
+func (e Event) StartTime() time.Time {
+	timeZone := time.UTC
+	if e.Venue.Location.TimeZone != "" {
+		location, err := time.LoadLocation(e.Venue.Location.TimeZone)
+		if err == nil {
+			timeZone = location
+		}
+	}
+
+	return time.Date(
+		e.Date.Year(), e.Date.Month(), e.Date.Day(),
+		e.Time.Hour(), e.Time.Minute(), e.Time.Second(), 0,
+		timeZone,
+	)
+}
+
 // Lineup contains the details of the event lineup.
 type Lineup struct {
 	Artist  Artist `json:"participant"`
@@ -43,14 +68,60 @@ type Location struct {
 	FullName string  `json:"name"`
 	Country  Country `json:"countryCode"`
 	Region   Region  `json:"regionCode"`
+
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	TimeZone  string  `json:"dateTimeZone"` // Europe/London
+}
+
+// UnmarshalJSON unmarshals a location, keeping unknown country and region codes
+// rather than failing. See Country and Region.
+func (l *Location) UnmarshalJSON(data []byte) error {
+	type location Location
+	aux := struct {
+		*location
+		Country string `json:"countryCode"`
+		Region  string `json:"regionCode"`
+	}{location: (*location)(l)}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	l.Country = Country{aux.Country}
+	l.Region = Region{aux.Region}
+	return nil
 }
 
 // Event contains the details of a tour.
 type Tour struct {
-	Id         string   `json:"tourId"`
-	Name       string   `json:"tourName"`
-	Slug       string   `json:"slug"`
-	FirstEvent *Date    `json:"minDate"`      // 2024-06-06
-	LastEvent  *Date    `json:"maxDate"`      // 2024-11-14
-	Countries  []string `json:"countryCodes"` // TODO use enum - requires all countries to be added
+	Id         string    `json:"tourId"`
+	Name       string    `json:"tourName"`
+	Slug       string    `json:"slug"`
+	FirstEvent *Date     `json:"minDate"`      // 2024-06-06
+	LastEvent  *Date     `json:"maxDate"`      // 2024-11-14
+	Countries  []Country `json:"countryCodes"` // [GB, US]
+}
+
+// UnmarshalJSON unmarshals a tour, keeping unknown country codes rather than failing.
+// See Country.
+func (t *Tour) UnmarshalJSON(data []byte) error {
+	type tour Tour
+	aux := struct {
+		*tour
+		Countries []string `json:"countryCodes"`
+	}{tour: (*tour)(t)}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	t.Countries = nil
+	if aux.Countries != nil {
+		t.Countries = make([]Country, 0, len(aux.Countries))
+		for _, countryCode := range aux.Countries {
+			t.Countries = append(t.Countries, Country{countryCode})
+		}
+	}
+	return nil
 }
@@ -24,7 +24,7 @@ func main() {
 		context.Background(),
 		twigots.FetchTicketListingsInput{
 			// Required
-			Country: twigots.CountryUnitedKingdom, // Only UK is supported at the moment
+			Country: twigots.CountryUnitedKingdom, // See twigots.Countries for all supported countries
 			// Optional. See all options in godoc
 			CreatedBefore: time.Now(),
 			CreatedAfter:  time.Now().Add(time.Duration(-5 * time.Minute)), // 5 mins ago
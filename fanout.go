@@ -0,0 +1,258 @@
+package twigots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultFanOutConcurrency = 4
+
+// FetchTicketListingsFanOutInput defines parameters when getting ticket listings
+// from several locations concurrently.
+//
+// Each location (a region, or a whole country) is fetched separately and concurrently,
+// with requests made under the client's shared rate limiter (see WithRateLimit). If the client
+// has no rate limit set, requests are limited to RecommendedRateLimit and RecommendedRateLimitBurst.
+// Results are then merged, newest first, with duplicates removed.
+type FetchTicketListingsFanOutInput struct {
+	// Countries to fetch ticket listings from. Each country is fetched separately.
+	// Countries that contain any of the regions set are not fetched as a whole.
+	Countries []Country
+
+	// Regions to fetch ticket listings from. Each region is fetched separately.
+	Regions []Region
+
+	// MaxNumber is the maximum number of ticket listings to fetch in total.
+	// See FetchTicketListingsInput.
+	// Defaults to 10.
+	MaxNumber int
+
+	// CreatedAfter is the time which ticket listings must have been created after to be fetched.
+	// See FetchTicketListingsInput.
+	CreatedAfter time.Time
+
+	// CreatedBefore is the time which ticket listings must have been created before to be fetched.
+	// See FetchTicketListingsInput.
+	// Defaults to current time.
+	CreatedBefore time.Time
+
+	// Concurrency is the maximum number of locations fetched at once.
+	// Defaults to DefaultFanOutConcurrency.
+	Concurrency int
+}
+
+func (f *FetchTicketListingsFanOutInput) applyDefaults() {
+	if f.MaxNumber == 0 {
+		f.MaxNumber = 10
+	}
+	if f.CreatedBefore.IsZero() {
+		f.CreatedBefore = time.Now()
+	}
+	if f.Concurrency <= 0 {
+		f.Concurrency = DefaultFanOutConcurrency
+	}
+}
+
+// Validate the input struct used to get ticket listings from several locations.
+// This is used internally to check the input, but can also be used externally.
+func (f FetchTicketListingsFanOutInput) Validate() error {
+	if len(f.Countries) == 0 && len(f.Regions) == 0 {
+		return errors.New("at least one country or region must be set")
+	}
+	for _, country := range f.Countries {
+		if !Countries.Contains(country) {
+			return fmt.Errorf("country '%s' is not valid", country)
+		}
+	}
+	for _, region := range f.Regions {
+		if !Regions.Contains(region) {
+			return fmt.Errorf("region '%s' is not valid", region)
+		}
+	}
+	if f.CreatedBefore.Before(f.CreatedAfter) {
+		return errors.New("created after time must be after the created before time")
+	}
+	if f.MaxNumber < 0 && f.CreatedAfter.IsZero() {
+		return errors.New("if not limiting number of ticket listings, created after must be set")
+	}
+	return nil
+}
+
+// locationInputs gets the input used to fetch each location.
+func (f FetchTicketListingsFanOutInput) locationInputs() []FetchTicketListingsInput {
+	inputs := make([]FetchTicketListingsInput, 0, len(f.Countries)+len(f.Regions))
+	countriesWithRegions := make(map[Country]bool, len(f.Regions))
+	for _, region := range f.Regions {
+		country := region.Country()
+		countriesWithRegions[country] = true
+		inputs = append(inputs, FetchTicketListingsInput{
+			Country: country,
+			Regions: []Region{region},
+		})
+	}
+	for _, country := range f.Countries {
+		if countriesWithRegions[country] {
+			continue
+		}
+		countriesWithRegions[country] = true // Prevents duplicate countries being fetched
+		inputs = append(inputs, FetchTicketListingsInput{Country: country})
+	}
+
+	for idx := range inputs {
+		inputs[idx].MaxNumber = f.MaxNumber
+		inputs[idx].CreatedAfter = f.CreatedAfter
+		inputs[idx].CreatedBefore = f.CreatedBefore
+	}
+
+	return inputs
+}
+
+// LocationError is an error fetching ticket listings from a single location.
+type LocationError struct {
+	Country Country
+	Region  Region // Unset if the whole country was fetched
+	Err     error
+}
+
+// Location gets the code of the location that failed e.g. GB or GBLO
+func (e LocationError) Location() string {
+	if e.Region.Value != "" {
+		return e.Region.Value
+	}
+	return e.Country.Value
+}
+
+func (e LocationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Location(), e.Err)
+}
+
+func (e LocationError) Unwrap() error {
+	return e.Err
+}
+
+// FanOutError is returned when fetching ticket listings from some locations fails.
+type FanOutError struct {
+	// NumLocations is the total number of locations fetched.
+	NumLocations int
+
+	// Errors of each location that failed.
+	Errors []LocationError
+}
+
+func (e *FanOutError) Error() string {
+	errorStrings := make([]string, 0, len(e.Errors))
+	for _, locationErr := range e.Errors {
+		errorStrings = append(errorStrings, locationErr.Error())
+	}
+	return fmt.Sprintf(
+		"failed to fetch ticket listings from %d of %d locations: %s",
+		len(e.Errors), e.NumLocations, strings.Join(errorStrings, "; "),
+	)
+}
+
+func (e *FanOutError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, locationErr := range e.Errors {
+		errs = append(errs, locationErr)
+	}
+	return errs
+}
+
+// FetchTicketListingsFanOut gets ticket listings from several locations concurrently using the specified input.
+//
+// If fetching some locations fails, the listings from the remaining locations are returned
+// along with a *FanOutError detailing the failures. If all locations fail, no listings are returned.
+func (c *Client) FetchTicketListingsFanOut(
+	ctx context.Context,
+	input FetchTicketListingsFanOutInput,
+) (TicketListings, error) {
+	input.applyDefaults()
+	err := input.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	// Locations are fetched using a copy of the client that uses the fan out rate limiter
+	fanOutClient := *c
+	fanOutClient.limiter = c.fanOutLimiter
+
+	locationInputs := input.locationInputs()
+	locationListings := make([]TicketListings, len(locationInputs))
+	locationErrors := make([]error, len(locationInputs))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, input.Concurrency)
+	for idx, locationInput := range locationInputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				locationErrors[idx] = ctx.Err()
+				return
+			}
+
+			locationListings[idx], locationErrors[idx] = fanOutClient.FetchTicketListings(ctx, locationInput)
+		}()
+	}
+	wg.Wait()
+
+	fanOutErr := &FanOutError{NumLocations: len(locationInputs)}
+	for idx, err := range locationErrors {
+		if err == nil {
+			continue
+		}
+		locationInput := locationInputs[idx]
+		locationErr := LocationError{Country: locationInput.Country, Err: err}
+		if len(locationInput.Regions) != 0 {
+			locationErr.Region = locationInput.Regions[0]
+		}
+		fanOutErr.Errors = append(fanOutErr.Errors, locationErr)
+	}
+
+	if len(fanOutErr.Errors) == len(locationInputs) {
+		return nil, fanOutErr
+	}
+
+	listings := mergeTicketListings(locationListings, input.MaxNumber)
+	if len(fanOutErr.Errors) != 0 {
+		return listings, fanOutErr
+	}
+
+	return listings, nil
+}
+
+// mergeTicketListings merges ticket listings, newest first, removing duplicates.
+// If maxNumber is positive, only the newest maxNumber listings are kept.
+func mergeTicketListings(listingsToMerge []TicketListings, maxNumber int) TicketListings {
+	numListings := 0
+	for _, listings := range listingsToMerge {
+		numListings += len(listings)
+	}
+
+	seenIds := make(map[string]bool, numListings)
+	merged := make(TicketListings, 0, numListings)
+	for _, listings := range listingsToMerge {
+		for _, listing := range listings {
+			if seenIds[listing.Id] {
+				continue
+			}
+			seenIds[listing.Id] = true
+			merged = append(merged, listing)
+		}
+	}
+
+	merged = merged.SortBy(Reverse(CompareByCreatedAt))
+	if maxNumber > 0 && len(merged) > maxNumber {
+		merged = merged[:maxNumber]
+	}
+
+	return merged
+}
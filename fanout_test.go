@@ -0,0 +1,138 @@
+package twigots_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchListingsFanOut(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+
+	// Create client
+	twicketsClient, err := twigots.NewClient(testAPIKey, twigots.WithRateLimit(0, 0))
+	require.NoError(t, err)
+
+	// Setup mock. London and the South return the same listings, which should be deduplicated.
+	// Scotland fails, which should be reported without failing the other regions.
+	responseJson, err := json.Marshal(getMockResponse(testEvents[:10], testTime, time.Minute))
+	require.NoError(t, err)
+	responder := httpmock.NewBytesResponder(http.StatusOK, responseJson)
+
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	httpmock.RegisterResponder("GET", getMockRegionUrl(twigots.RegionLondon, testTime), responder)
+	httpmock.RegisterResponder("GET", getMockRegionUrl(twigots.RegionSouth, testTime), responder)
+	httpmock.RegisterResponder(
+		"GET", getMockRegionUrl(twigots.RegionScotland, testTime),
+		httpmock.NewStringResponder(http.StatusInternalServerError, "error"),
+	)
+
+	listings, err := twicketsClient.FetchTicketListingsFanOut(
+		context.Background(),
+		twigots.FetchTicketListingsFanOutInput{
+			Regions:       []twigots.Region{twigots.RegionLondon, twigots.RegionSouth, twigots.RegionScotland},
+			MaxNumber:     8,
+			CreatedBefore: testTime,
+		},
+	)
+
+	var fanOutErr *twigots.FanOutError
+	require.ErrorAs(t, err, &fanOutErr)
+	require.Equal(t, 3, fanOutErr.NumLocations)
+	require.Len(t, fanOutErr.Errors, 1)
+	require.Equal(t, "GBSC", fanOutErr.Errors[0].Location())
+
+	// Listings should be deduplicated, newest first and limited to the max number
+	require.Len(t, listings, 8)
+	for idx, listing := range listings {
+		require.Equal(t, testEvents[idx], listing.Event.Name)
+	}
+}
+
+func TestFetchListingsFanOutAllFail(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+
+	twicketsClient, err := twigots.NewClient(testAPIKey, twigots.WithRateLimit(0, 0))
+	require.NoError(t, err)
+
+	httpmock.ActivateNonDefault(twicketsClient.Client())
+	httpmock.RegisterResponder(
+		"GET", getMockRegionUrl(twigots.RegionLondon, testTime),
+		httpmock.NewStringResponder(http.StatusInternalServerError, "error"),
+	)
+
+	listings, err := twicketsClient.FetchTicketListingsFanOut(
+		context.Background(),
+		twigots.FetchTicketListingsFanOutInput{
+			Countries:     []twigots.Country{twigots.CountryUnitedKingdom},
+			Regions:       []twigots.Region{twigots.RegionLondon},
+			CreatedBefore: testTime,
+		},
+	)
+	require.Nil(t, listings)
+
+	// The country should not be fetched as a whole as it contains a region
+	var fanOutErr *twigots.FanOutError
+	require.ErrorAs(t, err, &fanOutErr)
+	require.Equal(t, 1, fanOutErr.NumLocations)
+}
+
+func TestFetchListingsFanOutRateLimited(t *testing.T) {
+	testTime := time.Now().Truncate(time.Millisecond)
+	regions := []twigots.Region{
+		twigots.RegionEastAnglia, twigots.RegionLondon, twigots.RegionMidlands, twigots.RegionNorth,
+		twigots.RegionNorthEast, twigots.RegionNorthWest, twigots.RegionScotland,
+	}
+
+	responseJson, err := json.Marshal(getMockResponse(testEvents[:10], testTime, time.Minute))
+	require.NoError(t, err)
+	fetchFanOut := func(twicketsClient *twigots.Client) time.Duration {
+		httpmock.Reset()
+		httpmock.ActivateNonDefault(twicketsClient.Client())
+		httpmock.RegisterRegexpResponder(
+			"GET", regexp.MustCompile(`^https://www\.twickets\.live/services/catalogue`),
+			httpmock.NewBytesResponder(http.StatusOK, responseJson),
+		)
+
+		startTime := time.Now()
+		_, err := twicketsClient.FetchTicketListingsFanOut(
+			context.Background(),
+			twigots.FetchTicketListingsFanOutInput{
+				Regions:       regions,
+				CreatedBefore: testTime,
+				Concurrency:   len(regions),
+			},
+		)
+		require.NoError(t, err)
+		return time.Since(startTime)
+	}
+
+	// Without a rate limit set, the recommended rate limit should be used.
+	// 7 requests with a burst of 5 should take at least 2 / 5 seconds.
+	twicketsClient, err := twigots.NewClient(testAPIKey)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, fetchFanOut(twicketsClient), 350*time.Millisecond)
+
+	// Rate limiting can be disabled
+	twicketsClient, err = twigots.NewClient(testAPIKey, twigots.WithRateLimit(0, 0))
+	require.NoError(t, err)
+	require.Less(t, fetchFanOut(twicketsClient), 350*time.Millisecond)
+}
+
+func getMockRegionUrl(region twigots.Region, startTime time.Time) string {
+	return fmt.Sprintf(
+		"https://www.twickets.live/services/catalogue?api_key=%s&count=10&maxTime=%d&q=countryCode=%s,regionCode=%s",
+		testAPIKey,
+		startTime.UnixMilli(),
+		region.Country().Value,
+		region.Value,
+	)
+}
@@ -0,0 +1,121 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// Config is the configuration of a filter.
+// It can be used to create predicates from configuration files.
+//
+// All unset fields will match any ticket listing.
+type Config struct {
+	// Event name to match. See EventName.
+	Event string `json:"event,omitempty"`
+
+	// Minimum event name similarity. See EventName.
+	EventSimilarity float64 `json:"eventSimilarity,omitempty"`
+
+	// Regions to match. See EventRegion.
+	Regions []twigots.Region `json:"regions,omitempty"`
+
+	// Number of tickets to match. See NumTickets.
+	NumTickets int `json:"numTickets,omitempty"`
+
+	// Maximum ticket price incl fee. See MaxTicketPriceInclFeeInCurrency.
+	// Can be a number (e.g. 80) or a money string (e.g. "£80"). See Money.
+	MaxTicketPrice Money `json:"maxTicketPrice,omitzero"`
+
+	// Minimum discount. See MinDiscount.
+	MinDiscount float64 `json:"minDiscount,omitempty"`
+
+	// Converter used to convert prices of listings to the currency of MaxTicketPrice.
+	// See MaxTicketPriceInclFeeInCurrency. This cannot be set in configuration files.
+	Converter twigots.Converter `json:"-"`
+}
+
+// Predicates creates the predicates described by the config.
+func (c Config) Predicates() []TicketListingPredicate {
+	return []TicketListingPredicate{
+		EventName(c.Event, c.EventSimilarity),
+		EventRegion(c.Regions...),
+		NumTickets(c.NumTickets),
+		MaxTicketPriceInclFeeInCurrency(c.MaxTicketPrice.Price, c.Converter),
+		MinDiscount(c.MinDiscount),
+	}
+}
+
+// Predicate creates a single predicate that matches ticket listings
+// satisfying all the predicates described by the config.
+func (c Config) Predicate() TicketListingPredicate {
+	predicates := c.Predicates()
+	return func(listing twigots.TicketListing) bool {
+		return TicketListingMatchesAllPredicates(listing, predicates...)
+	}
+}
+
+// Money is an amount of money used in configuration.
+//
+// It can be given either as a number (e.g. 80) or a money string with
+// an optional currency (e.g. "£80", "80 EUR" or "1.234,50 €"). See twigots.ParsePrice.
+// Money without a currency has an unset currency.
+type Money struct{ twigots.Price }
+
+// ParseMoney parses money from a number or money string.
+func ParseMoney(money string) (Money, error) {
+	price, err := twigots.ParsePrice(money, twigots.Currency{})
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{price}, nil
+}
+
+// IsZero returns whether the money is unset.
+func (m Money) IsZero() bool {
+	return m.Price == twigots.Price{}
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var money string
+	err := json.Unmarshal(data, &money)
+	if err == nil {
+		*m, err = ParseMoney(money)
+		return err
+	}
+
+	var number float64
+	err = json.Unmarshal(data, &number)
+	if err != nil {
+		return fmt.Errorf("money must be a number or a string: %w", err)
+	}
+
+	exponent := twigots.Currency{}.Exponent()
+	*m = Money{twigots.Price{Amount: int64(math.Round(number * math.Pow10(exponent)))}}
+	return nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.Currency.Value == "" {
+		return json.Marshal(m.Number())
+	}
+	return json.Marshal(m.String())
+}
+
+// String gets the money as a string that can be parsed by ParseMoney.
+func (m Money) String() string {
+	if m.Currency.Value == "" {
+		return strconv.FormatFloat(m.Number(), 'f', -1, 64)
+	}
+	return m.Price.String()
+}
+
+// Set the money from a string. This allows money to be used as a flag. See flag.Value.
+func (m *Money) Set(money string) error {
+	var err error
+	*m, err = ParseMoney(money)
+	return err
+}
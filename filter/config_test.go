@@ -0,0 +1,36 @@
+package filter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMaxTicketPriceJSON(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{"maxTicketPrice": 80.5}`), &config)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Amount: 8050}, config.MaxTicketPrice.Price)
+
+	err = json.Unmarshal([]byte(`{"maxTicketPrice": "£80"}`), &config)
+	require.NoError(t, err)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyGBP, Amount: 8000}, config.MaxTicketPrice.Price)
+
+	err = json.Unmarshal([]byte(`{"maxTicketPrice": "eighty"}`), &config)
+	require.Error(t, err)
+
+	// Should round trip, and be omitted when unset
+	data, err := json.Marshal(Config{MaxTicketPrice: Money{twigots.Price{Currency: twigots.CurrencyGBP, Amount: 8000}}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"maxTicketPrice": "£80.00"}`, string(data))
+
+	data, err = json.Marshal(Config{MaxTicketPrice: Money{twigots.Price{Amount: 8050}}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"maxTicketPrice": 80.5}`, string(data))
+
+	data, err = json.Marshal(Config{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(data))
+}
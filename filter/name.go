@@ -56,20 +56,28 @@ func EventName(eventName string, minimumSimilarity float64) TicketListingPredica
 	}
 
 	return func(listing twigots.TicketListing) bool {
-		// Normalise event names
-		desiredEventName := normaliseString(eventName)
-		listingEventName := normaliseString(listing.Event.Name)
-
-		// Add spaces on either side of event name to help prevent
-		// matches of word that is contained within another word
-		desiredEventName = fmt.Sprintf(" %s ", desiredEventName)
-		listingEventName = fmt.Sprintf(" %s ", listingEventName)
-
-		eventSimilarity := substringSimilarity(desiredEventName, listingEventName)
+		eventSimilarity := EventNameSimilarity(eventName, listing.Event.Name)
 		return eventSimilarity >= minimumSimilarity
 	}
 }
 
+// EventNameSimilarity calculates how similar a desired event name is to an actual event name.
+//
+// Similarity is a float between 0 and 1 (with 0 representing no similarity and 1 representing an exact match).
+// Both names are normalised before comparison. See README for details on how similarity is calculated.
+func EventNameSimilarity(desiredEventName, actualEventName string) float64 {
+	// Normalise event names
+	desiredEventName = normaliseString(desiredEventName)
+	actualEventName = normaliseString(actualEventName)
+
+	// Add spaces on either side of event name to help prevent
+	// matches of word that is contained within another word
+	desiredEventName = fmt.Sprintf(" %s ", desiredEventName)
+	actualEventName = fmt.Sprintf(" %s ", actualEventName)
+
+	return substringSimilarity(desiredEventName, actualEventName)
+}
+
 // normaliseString normalizes a given string by removing accents, converting to lowercase,
 // removing leading/trailing whitespace, replacing '&' with 'and', and replacing special characters with spaces.
 func normaliseString(eventName string) string {
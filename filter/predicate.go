@@ -68,6 +68,42 @@ func MaxTicketPriceInclFee(maxPrice float64) TicketListingPredicate {
 	}
 }
 
+// MaxTicketPriceInclFeeInCurrency creates a predicate that matches ticket listings with a price incl fee
+// at or below the specified max.
+//
+// If a converter is given, prices of ticket listings in a different currency to the max price are
+// converted to the currency of the max price before being compared, so the max price can be given
+// in the user's home currency. Ticket listings that cannot be converted will not match.
+// If converter is nil, ticket listings in a different currency will not match.
+//
+// If the currency of maxPrice is unset, this is the same as MaxTicketPriceInclFee.
+// Set maxPrice to <=0 to match any price.
+func MaxTicketPriceInclFeeInCurrency(maxPrice twigots.Price, converter twigots.Converter) TicketListingPredicate {
+	if maxPrice.Currency.Value == "" {
+		return MaxTicketPriceInclFee(maxPrice.Number())
+	}
+	if maxPrice.Amount <= 0 {
+		return alwaysPredicate
+	}
+
+	return func(listing twigots.TicketListing) bool {
+		ticketPrice := listing.TicketPriceInclFee()
+		if ticketPrice.Currency != maxPrice.Currency {
+			if converter == nil {
+				return false
+			}
+
+			var err error
+			ticketPrice, err = converter.Convert(ticketPrice, maxPrice.Currency)
+			if err != nil {
+				return false
+			}
+		}
+		result, err := ticketPrice.CheckedCmp(maxPrice)
+		return err == nil && result <= 0
+	}
+}
+
 // MinDiscount creates a predicate that matches ticket listings with a discount above the specified min.
 //
 // Discount is specified as a float, between 0 and 1 (with 0 representing no discount and 1 representing 100% off).
@@ -79,3 +79,42 @@ func TestMaxTicketPriceInclFeePredicate(t *testing.T) {
 	match = predicate(listing)
 	require.False(t, match)
 }
+
+func TestMaxTicketPriceInclFeeInCurrencyPredicate(t *testing.T) {
+	listing := twigots.TicketListing{
+		NumTickets:        2,
+		TotalPriceExclFee: twigots.Price{Currency: twigots.CurrencyGBP, Amount: 2400},
+		TwicketsFee:       twigots.Price{Currency: twigots.CurrencyGBP, Amount: 300}, // £13.50 per ticket incl fee
+	}
+
+	gbp := func(amount int64) twigots.Price { return twigots.Price{Currency: twigots.CurrencyGBP, Amount: amount} }
+	require.True(t, MaxTicketPriceInclFeeInCurrency(gbp(1350), nil)(listing))
+	require.False(t, MaxTicketPriceInclFeeInCurrency(gbp(1349), nil)(listing))
+
+	// Listings in a different currency should not match
+	eur := twigots.Price{Currency: twigots.CurrencyEUR, Amount: 5000}
+	require.False(t, MaxTicketPriceInclFeeInCurrency(eur, nil)(listing))
+
+	// Prices without a currency should match any currency
+	require.True(t, MaxTicketPriceInclFeeInCurrency(twigots.Price{Amount: 1350}, nil)(listing))
+}
+
+func TestMaxTicketPriceInclFeeInCurrencyPredicateConverter(t *testing.T) {
+	listing := twigots.TicketListing{
+		NumTickets:        1,
+		TotalPriceExclFee: twigots.Price{Currency: twigots.CurrencyEUR, Amount: 9000},
+		TwicketsFee:       twigots.Price{Currency: twigots.CurrencyEUR, Amount: 1000}, // €100 per ticket incl fee
+	}
+	converter := twigots.Rates{
+		Base:  twigots.CurrencyGBP,
+		Rates: map[twigots.Currency]float64{twigots.CurrencyEUR: 1.25}, // €100 = £80
+	}
+
+	gbp := func(amount int64) twigots.Price { return twigots.Price{Currency: twigots.CurrencyGBP, Amount: amount} }
+	require.True(t, MaxTicketPriceInclFeeInCurrency(gbp(8000), converter)(listing))
+	require.False(t, MaxTicketPriceInclFeeInCurrency(gbp(7999), converter)(listing))
+
+	// Listings that cannot be converted should not match
+	usd := twigots.Price{Currency: twigots.CurrencyUSD, Amount: 50000}
+	require.False(t, MaxTicketPriceInclFeeInCurrency(usd, converter)(listing))
+}
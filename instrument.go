@@ -0,0 +1,249 @@
+package twigots
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"golang.org/x/time/rate"
+)
+
+// FeedRequest is a request for a single page of the ticket listings feed.
+type FeedRequest struct {
+	// Query is the location query of the feed e.g. countryCode=GB,regionCode=GBLO
+	Query string
+
+	// MaxTime is the time that listings in the page were created before. Zero if unset.
+	MaxTime time.Time
+}
+
+// newFeedRequest describes the request for a feed url.
+func newFeedRequest(feedUrl string) FeedRequest {
+	parsedUrl, err := url.Parse(feedUrl)
+	if err != nil {
+		return FeedRequest{}
+	}
+
+	queryParams := parsedUrl.Query()
+	request := FeedRequest{Query: queryParams.Get("q")}
+	maxTime, err := strconv.ParseInt(queryParams.Get("maxTime"), 10, 64)
+	if err == nil {
+		request.MaxTime = time.UnixMilli(maxTime)
+	}
+
+	return request
+}
+
+// FeedResponse is the result of a request for a single page of the ticket listings feed.
+type FeedResponse struct {
+	// StatusCode of the response. Zero if no response was received.
+	StatusCode int
+
+	// NumListings is the number of (non-delisted) ticket listings in the page.
+	NumListings int
+
+	// NumDecodeErrors is the number of entries in the page that were skipped
+	// as they failed to decode. Always zero unless decoding leniently.
+	NumDecodeErrors int
+
+	Duration time.Duration
+	Err      error
+}
+
+// FetchResult is the result of a call to FetchTicketListings.
+type FetchResult struct {
+	Input    FetchTicketListingsInput
+	NumPages int
+	Listings TicketListings
+	Duration time.Duration
+	Err      error
+}
+
+// Metrics records metrics of requests made by a client.
+// See the metrics/prometheus package for a Prometheus implementation.
+type Metrics interface {
+	// ObserveFeedRequest is called after every feed page request.
+	ObserveFeedRequest(request FeedRequest, response FeedResponse)
+
+	// ObserveFetch is called after every call to FetchTicketListings.
+	ObserveFetch(result FetchResult)
+}
+
+// Tracer traces requests made by a client.
+// See the tracing/otel package for an OpenTelemetry implementation.
+type Tracer interface {
+	// StartFeedRequest is called before every feed page request. The returned context is used
+	// for the request, and the returned function is called once the request completes.
+	StartFeedRequest(ctx context.Context, request FeedRequest) (context.Context, func(FeedResponse))
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveFeedRequest(FeedRequest, FeedResponse) {}
+func (noopMetrics) ObserveFetch(FetchResult)                     {}
+
+type noopTracer struct{}
+
+func (noopTracer) StartFeedRequest(ctx context.Context, _ FeedRequest) (context.Context, func(FeedResponse)) {
+	return ctx, func(FeedResponse) {}
+}
+
+// ClientOption is an option used to configure a client.
+type ClientOption func(*Client)
+
+// WithMetrics records metrics of requests made by the client. Defaults to no metrics.
+func WithMetrics(metrics Metrics) ClientOption {
+	return func(c *Client) {
+		if metrics != nil {
+			c.metrics = metrics
+		}
+	}
+}
+
+// WithTracer traces requests made by the client. Defaults to no tracing.
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *Client) {
+		if tracer != nil {
+			c.tracer = tracer
+		}
+	}
+}
+
+// WithLogger logs requests made by the client, including each feed page fetched,
+// pagination decisions and retries. The api key is redacted from logged urls.
+// Defaults to no logging.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithRetries retries failed feed requests up to maxRetries times, with backoff.
+// Requests are retried on connection errors, rate limiting (429) and server errors (5xx).
+// Retries are rate limited along with all other requests (see WithRateLimit). Defaults to no retries.
+func WithRetries(maxRetries int) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = max(maxRetries, 0)
+	}
+}
+
+// WithLenientDecoding decodes each entry of the ticket listings feed independently,
+// skipping entries that fail to decode rather than failing the whole request.
+// See UnmarshalTwicketsFeedJsonLenient.
+//
+// onDecodeError is called with each entry that is skipped, and can be nil.
+// It may be called concurrently if the client is used concurrently.
+// Skipped entries are also logged (see WithLogger). Defaults to strict decoding.
+func WithLenientDecoding(onDecodeError func(FeedDecodeError)) ClientOption {
+	return func(c *Client) {
+		c.lenientDecoding = true
+		c.onDecodeError = onDecodeError
+	}
+}
+
+// WithRecording records every request made by the client, and its response, to a cassette directory.
+// The api key is redacted from recordings. Recordings can be replayed using WithReplay.
+// See NewRecordingTransport.
+func WithRecording(directory string) ClientOption {
+	return func(c *Client) {
+		httpClient := c.client.GetClient()
+		httpClient.Transport = NewRecordingTransport(directory, httpClient.Transport)
+	}
+}
+
+// WithReplay serves requests made by the client from a cassette directory recorded using WithRecording,
+// rather than making real requests. Requests that have not been recorded fail.
+// See NewReplayTransport.
+func WithReplay(directory string) ClientOption {
+	return func(c *Client) {
+		c.client.GetClient().Transport = NewReplayTransport(directory)
+	}
+}
+
+// Recommended rate limit of feed requests made by a client, which can be used with WithRateLimit.
+const (
+	RecommendedRateLimit      = 5 // Requests per second
+	RecommendedRateLimitBurst = 5
+)
+
+// WithRateLimit limits the rate of feed requests made by the client.
+// The limit is shared by all requests made by the client, including concurrent requests
+// made by FetchTicketListingsFanOut. Set requestsPerSecond to zero or less for no limit.
+//
+// Defaults to no limit, except for FetchTicketListingsFanOut, which makes many requests at once
+// and so defaults to RecommendedRateLimit and RecommendedRateLimitBurst.
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(c *Client) {
+		if requestsPerSecond <= 0 {
+			c.limiter = rate.NewLimiter(rate.Inf, 0)
+		} else {
+			c.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), max(burst, 1))
+		}
+		c.fanOutLimiter = c.limiter
+	}
+}
+
+// Backoff between retries of failed requests, doubling each retry.
+const (
+	minRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff = 10 * time.Second
+)
+
+// retryBackoff gets the time to wait before retrying a request that has failed attempt+1 times.
+func retryBackoff(attempt int) time.Duration {
+	backoff := minRetryBackoff
+	for range attempt {
+		backoff *= 2
+		if backoff >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
+}
+
+func shouldRetry(response *req.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if response == nil || response.Response == nil {
+		return false
+	}
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+}
+
+var apiKeyQueryParam = regexp.MustCompile(`api_key=[^&]*`)
+
+// redactFeedUrl replaces the api key in a feed url so it is safe to log.
+// If the url cannot be parsed, the api key is still redacted.
+func redactFeedUrl(feedUrl string) string {
+	parsedUrl, err := url.Parse(feedUrl)
+	if err != nil {
+		return apiKeyQueryParam.ReplaceAllString(feedUrl, "api_key=REDACTED")
+	}
+
+	queryParams := parsedUrl.Query()
+	if !queryParams.Has("api_key") {
+		return apiKeyQueryParam.ReplaceAllString(feedUrl, "api_key=REDACTED")
+	}
+	queryParams.Set("api_key", "REDACTED")
+	parsedUrl.RawQuery = queryParams.Encode()
+
+	return parsedUrl.String()
+}
+
+// redactError redacts the api key from the url of an error, if it has one.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactFeedUrl(urlErr.URL)
+	}
+	return err
+}
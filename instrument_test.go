@@ -0,0 +1,63 @@
+package twigots
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactFeedUrl(t *testing.T) {
+	require.Equal(
+		t,
+		"https://www.twickets.live/services/catalogue?api_key=REDACTED&count=10",
+		redactFeedUrl("https://www.twickets.live/services/catalogue?api_key=secret&count=10"),
+	)
+
+	// Urls that cannot be parsed should still be redacted
+	require.Equal(
+		t,
+		"https://www.twickets.live/%zz?api_key=REDACTED&count=10",
+		redactFeedUrl("https://www.twickets.live/%zz?api_key=secret&count=10"),
+	)
+	require.Equal(
+		t,
+		"https://www.twickets.live/services/catalogue?api_key=REDACTED",
+		redactFeedUrl("https://www.twickets.live/services/catalogue?api_key=secret;count=10"),
+	)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	require.Equal(t, 500*time.Millisecond, retryBackoff(0))
+	require.Equal(t, time.Second, retryBackoff(1))
+	require.Equal(t, 8*time.Second, retryBackoff(4))
+	require.Equal(t, 10*time.Second, retryBackoff(5))
+	require.Equal(t, 10*time.Second, retryBackoff(100))
+}
+
+func TestRetriesRateLimited(t *testing.T) {
+	// A burst of two requests, that is not refilled during the test
+	client, err := NewClient("test", WithRetries(2), WithRateLimit(0.001, 2))
+	require.NoError(t, err)
+
+	apiUrl := "https://www.twickets.live/services/catalogue?api_key=test"
+	httpmock.ActivateNonDefault(client.Client())
+	httpmock.RegisterResponder("GET", apiUrl, httpmock.ResponderFromResponse(
+		httpmock.NewStringResponse(http.StatusInternalServerError, "error"),
+	).Then(httpmock.NewStringResponder(http.StatusOK, `{"responseData": []}`)))
+
+	_, _, err = client.fetchFeed(context.Background(), apiUrl)
+	require.NoError(t, err)
+	require.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// The retry should have used the rate limit, so further requests wait
+	require.Less(t, client.limiter.Tokens(), 1.0)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, err = client.fetchFeed(ctx, apiUrl)
+	require.Error(t, err)
+	require.Equal(t, 2, httpmock.GetTotalCallCount())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 )
 
 // TicketListing is a listing of ticket(s) on Twickets
@@ -21,14 +22,32 @@ type TicketListing struct {
 	// Use TicketPriceInclFee to get the price of a single ticket, including fee.
 	TotalPriceExclFee Price `json:"totalSellingPrice"`
 
+	// TotalPriceFeeApplicable is the part of the total price of all tickets, excluding fee,
+	// that the twickets fee is charged on. This is usually the same as TotalPriceExclFee.
+	TotalPriceFeeApplicable Price `json:"totalSellingPriceFeeApplicable"`
+
 	// TwicketsFee is the total twickets fee for all tickets.
 	// Use TwicketsFeePerTicket to get the twickets fee per ticket.
 	TwicketsFee Price `json:"totalTwicketsFee"`
 
+	// ReportedTwicketsFeePerTicket is the twickets fee per ticket, as reported by the feed.
+	// This should match TwicketsFeePerTicket, which is calculated from the total fee.
+	// Use Breakdown to check whether they differ.
+	ReportedTwicketsFeePerTicket Price `json:"twicketsFeePerTicket"`
+
 	// OriginalTotalPrice is the original total price of all tickets, including any fee.
 	// Use OriginalTicketPrice to get the original price of a single ticket, including any fee.
 	OriginalTotalPrice Price `json:"faceValuePrice"`
 
+	// OriginalSellingPrice is the price of a single ticket, excluding fee, when the listing was first created.
+	// This will differ from the current price if the seller has re-priced the listing.
+	// Use PriceChange to see how the price has changed.
+	OriginalSellingPrice Price `json:"originalSellingPrice"`
+
+	// PreviousSellingPrice is the price of a single ticket, excluding fee, before the listing was last re-priced.
+	// Use PriceChange to see how the price has changed.
+	PreviousSellingPrice Price `json:"previousSellingPrice"`
+
 	SellerWillConsiderOffers bool `json:"sellerWillConsiderOffers"`
 
 	// The type of the ticket e.g. seated, Standing, Box etc.
@@ -56,10 +75,10 @@ func (l TicketListing) URL() string {
 //
 // Use TicketPriceInclFee to get the price of a single ticket, including fee.
 func (l TicketListing) TicketPriceExclFee() Price {
-	return l.TotalPriceExclFee.Divide(l.NumTickets).Add(l.TwicketsFeePerTicket())
+	return l.TotalPriceExclFee.Divide(l.NumTickets)
 }
 
-// TotalPriceExclFee is the total price of all tickets, including fee.
+// TotalPriceInclFee is the total price of all tickets, including fee.
 //
 // Use TotalPriceExclFee to get the total price of all tickets, excluding fee.
 //
@@ -70,7 +89,7 @@ func (l TicketListing) TotalPriceInclFee() Price {
 	return l.TotalPriceExclFee.Add(l.TwicketsFee)
 }
 
-// TicketPriceExclFee is price of a single ticket, including fee.
+// TicketPriceInclFee is price of a single ticket, including fee.
 //
 // Use TotalPriceExclFee to get the total price of all tickets, excluding fee.
 //
@@ -88,7 +107,7 @@ func (l TicketListing) TwicketsFeePerTicket() Price {
 	return l.TwicketsFee.Divide(l.NumTickets)
 }
 
-// OriginalTotalPrice is the original price of a single ticket, including any fee.
+// OriginalTicketPrice is the original price of a single ticket, including any fee.
 //
 // Use OriginalTotalPrice to get the original total price of all tickets, including any fee.
 func (l TicketListing) OriginalTicketPrice() Price {
@@ -119,6 +138,160 @@ func (l TicketListing) DiscountString() string {
 	return discountString + "%"
 }
 
+// PriceChange describes how the price of a single ticket, excluding fee,
+// has changed since a listing was created.
+type PriceChange struct {
+	// Original price of a single ticket, excluding fee, when the listing was created.
+	Original Price
+
+	// Previous price of a single ticket, excluding fee, before the listing was last re-priced.
+	Previous Price
+
+	// Current price of a single ticket, excluding fee.
+	Current Price
+}
+
+// Reduced returns whether the current price is lower than the original price.
+func (c PriceChange) Reduced() bool {
+	return c.Original.Amount != 0 && c.Current.Amount < c.Original.Amount
+}
+
+// Reduction is the amount the price has been reduced by since the listing was created.
+// Returns a zero price if the price has not been reduced.
+func (c PriceChange) Reduction() Price {
+	if !c.Reduced() {
+		return Price{Currency: c.Current.Currency}
+	}
+	return c.Original.Subtract(c.Current)
+}
+
+// ReductionFraction is the fraction the price has been reduced by since the listing was created.
+//
+// Reduction is returned as a value between 0 and 1 (with 1 representing 100% off).
+// Returns 0 if the price has not been reduced.
+func (c PriceChange) ReductionFraction() float64 {
+	if !c.Reduced() {
+		return 0
+	}
+	return c.Reduction().Number() / c.Original.Number()
+}
+
+// PriceChange reports how the price of a single ticket, excluding fee, has changed since the listing was created.
+//
+// If the listing does not include its original selling price, the original and previous
+// prices will be the same as the current price (i.e. the price will be reported as unchanged).
+func (l TicketListing) PriceChange() PriceChange {
+	currentPrice := l.TicketPriceExclFee()
+
+	originalPrice := l.OriginalSellingPrice
+	if originalPrice.Amount == 0 {
+		originalPrice = currentPrice
+	}
+
+	previousPrice := l.PreviousSellingPrice
+	if previousPrice.Amount == 0 {
+		previousPrice = originalPrice
+	}
+
+	return PriceChange{
+		Original: originalPrice,
+		Previous: previousPrice,
+		Current:  currentPrice,
+	}
+}
+
+// PriceBreakdown breaks down the price of ticket(s) into face value, seller price and fee.
+type PriceBreakdown struct {
+	// FaceValue is the original price, including any fee.
+	FaceValue Price
+
+	// SellerPrice is the price set by the seller, excluding fee.
+	SellerPrice Price
+
+	// Fee is the twickets fee.
+	Fee Price
+
+	// Price is the price paid by the buyer, including fee.
+	Price Price
+
+	// FeeFraction is the fee as a fraction of the seller price the fee is charged on.
+	// e.g. 0.1 is a 10% fee.
+	FeeFraction float64
+
+	// Markup is the price paid by the buyer, including fee, minus the face value.
+	// This will be negative if the ticket is being sold below face value.
+	Markup Price
+
+	// MarkupFraction is the markup as a fraction of the face value.
+	// e.g. 0.1 is 10% above face value, -0.2 is 20% below face value.
+	// Zero if the face value is not known.
+	MarkupFraction float64
+}
+
+// Breakdown breaks down the price of a ticket listing, both per ticket and in total.
+type Breakdown struct {
+	PerTicket PriceBreakdown
+	Total     PriceBreakdown
+
+	// FeeDrift is the fee per ticket calculated from the total fee, minus the fee per ticket
+	// reported by the feed. Zero if the feed does not report a fee per ticket.
+	// Use HasFeeDrift to check whether the fees differ by more than rounding.
+	FeeDrift Price
+}
+
+// HasFeeDrift returns whether the fee per ticket calculated from the total fee differs from
+// the fee per ticket reported by the feed by more than a minor unit (which can be caused by rounding).
+// This suggests the way twickets calculates fees has changed.
+func (b Breakdown) HasFeeDrift() bool {
+	return absInt64(b.FeeDrift.Amount) > 1
+}
+
+// Breakdown breaks down the price of the listing into face value, seller price, fee and markup,
+// both per ticket and in total.
+func (l TicketListing) Breakdown() Breakdown {
+	feeApplicable := l.TotalPriceFeeApplicable
+	if feeApplicable.Amount == 0 {
+		feeApplicable = l.TotalPriceExclFee
+	}
+
+	total := newPriceBreakdown(l.OriginalTotalPrice, l.TotalPriceExclFee, l.TwicketsFee, feeApplicable)
+	perTicket := newPriceBreakdown(
+		l.OriginalTicketPrice(),
+		l.TicketPriceExclFee(),
+		l.TwicketsFeePerTicket(),
+		feeApplicable.Divide(l.NumTickets),
+	)
+
+	feeDrift := Price{Currency: perTicket.Fee.Currency}
+	if l.ReportedTwicketsFeePerTicket.Amount != 0 {
+		feeDrift = perTicket.Fee.Subtract(l.ReportedTwicketsFeePerTicket)
+	}
+
+	return Breakdown{
+		PerTicket: perTicket,
+		Total:     total,
+		FeeDrift:  feeDrift,
+	}
+}
+
+func newPriceBreakdown(faceValue, sellerPrice, fee, feeApplicable Price) PriceBreakdown {
+	price := sellerPrice.Add(fee)
+	breakdown := PriceBreakdown{
+		FaceValue:   faceValue,
+		SellerPrice: sellerPrice,
+		Fee:         fee,
+		Price:       price,
+		Markup:      price.Subtract(faceValue),
+	}
+	if feeApplicable.Amount != 0 {
+		breakdown.FeeFraction = fee.Number() / feeApplicable.Number()
+	}
+	if faceValue.Amount != 0 {
+		breakdown.MarkupFraction = breakdown.Markup.Number() / faceValue.Number()
+	}
+	return breakdown
+}
+
 // TicketListings is a slice of ticket listings.
 type TicketListings []TicketListing
 
@@ -133,23 +306,112 @@ func (l TicketListings) GetById(id string) *TicketListing {
 }
 
 func UnmarshalTwicketsFeedJson(data []byte) ([]TicketListing, error) {
+	page, err := unmarshalFeedPage(data, false)
+	if err != nil {
+		return nil, err
+	}
+	return page.listings, nil
+}
+
+// FeedDecodeError is an error decoding a single entry of the ticket listings feed.
+type FeedDecodeError struct {
+	// Index of the entry in the feed
+	Index int
+
+	// Raw JSON of the entry
+	Raw json.RawMessage
+
+	Err error
+}
+
+func (e FeedDecodeError) Error() string {
+	return fmt.Sprintf("failed to decode feed entry %d: %s", e.Index, e.Err)
+}
+
+func (e FeedDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalTwicketsFeedJsonLenient is a lenient version of UnmarshalTwicketsFeedJson.
+// Each entry of the feed is decoded independently, so entries that fail to decode
+// are skipped rather than failing the whole feed. The listings that were decoded are
+// returned along with an error for each entry that was skipped.
+//
+// An error is only returned if the feed itself is malformed.
+func UnmarshalTwicketsFeedJsonLenient(data []byte) ([]TicketListing, []FeedDecodeError, error) {
+	page, err := unmarshalFeedPage(data, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return page.listings, page.decodeErrors, nil
+}
+
+// Delist is a ticket listing delisted from the ticket listings feed,
+// e.g. because it has sold or been removed by the seller.
+type Delist struct {
+	// Id of the delisted ticket listing. See TicketListing.Id.
+	Id string
+
+	// DelistedAt is the time the listing was delisted.
+	// Zero if the feed did not include the time.
+	DelistedAt time.Time
+}
+
+// feedPage is a page of the ticket listings feed.
+type feedPage struct {
+	listings []TicketListing
+
+	// delists are the delisted entries in the page.
+	// These are entries without a listing.
+	delists []Delist
+
+	// decodeErrors are errors of entries that failed to decode.
+	// Only set if decoding leniently.
+	decodeErrors []FeedDecodeError
+}
+
+func unmarshalFeedPage(data []byte, lenient bool) (feedPage, error) {
 	response := struct {
-		ResponseData []struct { // nolint
-			Listings *TicketListing `json:"catalogBlockSummary"`
-		} `json:"responseData"`
+		ResponseData []json.RawMessage `json:"responseData"`
 	}{}
 	err := json.Unmarshal(data, &response)
 	if err != nil {
-		return nil, err
+		return feedPage{}, err
 	}
 
 	// Get non null listings. Listings are null if they have been delisted
-	listings := make([]TicketListing, 0, len(response.ResponseData))
-	for _, responseData := range response.ResponseData {
-		if responseData.Listings != nil {
-			listings = append(listings, *responseData.Listings)
+	page := feedPage{listings: make([]TicketListing, 0, len(response.ResponseData))}
+	for idx, rawEntry := range response.ResponseData {
+		entry := struct {
+			Listing         *TicketListing `json:"catalogBlockSummary"`
+			Delist          bool           `json:"delist"`
+			BlockIdToDelist *string        `json:"blockIdToDelist"`
+			Timestamp       *UnixTime      `json:"timestamp"`
+		}{}
+		err := json.Unmarshal(rawEntry, &entry)
+		if err != nil {
+			if !lenient {
+				return feedPage{}, err
+			}
+			page.decodeErrors = append(page.decodeErrors, FeedDecodeError{
+				Index: idx,
+				Raw:   rawEntry,
+				Err:   err,
+			})
+			continue
+		}
+
+		switch {
+		case entry.Listing != nil:
+			page.listings = append(page.listings, *entry.Listing)
+		case entry.Delist && entry.BlockIdToDelist != nil:
+			delist := Delist{Id: *entry.BlockIdToDelist}
+			if entry.Timestamp != nil {
+				delist.DelistedAt = entry.Timestamp.Time
+			}
+			page.delists = append(page.delists, delist)
 		}
 	}
 
-	return listings, nil
+	return page, nil
 }
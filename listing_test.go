@@ -1,6 +1,7 @@
 package twigots_test
 
 import (
+	"encoding/json"
 	"io"
 	"os"
 	"path/filepath"
@@ -27,6 +28,13 @@ func TestUnmarshalFeedJson(t *testing.T) {
 	require.Equal(t, "£180.00", listings[0].TotalPriceExclFee.String())
 	require.Equal(t, "£38.25", listings[0].TwicketsFee.String())
 	require.Equal(t, "£255.00", listings[0].OriginalTotalPrice.String())
+	require.Equal(t, twigots.CountryUnitedKingdom, listings[0].Event.Venue.Location.Country)
+	require.Equal(t, twigots.RegionLondon, listings[0].Event.Venue.Location.Region)
+	require.Equal(
+		t,
+		[]twigots.Country{twigots.CountryUnitedKingdom, twigots.CountryUnitedStates},
+		listings[0].Tour.Countries,
+	)
 
 	require.Equal(t, "Mean Girls", listings[1].Event.Name)
 	require.Empty(t, listings[1].Event.Lineup)
@@ -56,6 +64,78 @@ func TestUnmarshalFeedJson(t *testing.T) {
 	require.Equal(t, "£322.00", listings[3].OriginalTotalPrice.String())
 }
 
+func TestUnmarshalFeedJsonUnknownCodes(t *testing.T) {
+	feedJson := `{"responseData": [{"catalogBlockSummary": {
+		"blockId": "1",
+		"totalSellingPrice": {"currencyCode": "XYZ", "amountInCents": 1000},
+		"event": {"venue": {"location": {"countryCode": "XX", "regionCode": "XXAB"}}},
+		"tour": {"countryCodes": ["GB", "XX"]}
+	}}]}`
+
+	// Unknown codes should be kept rather than failing the whole feed
+	listings, err := twigots.UnmarshalTwicketsFeedJson([]byte(feedJson))
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+
+	listing := listings[0]
+	require.Equal(t, "XYZ", listing.TotalPriceExclFee.Currency.Value)
+	require.False(t, twigots.Currencies.Contains(listing.TotalPriceExclFee.Currency))
+	require.Equal(t, "XX", listing.Event.Venue.Location.Country.Value)
+	require.Equal(t, "XXAB", listing.Event.Venue.Location.Region.Value)
+	require.Equal(t, []twigots.Country{twigots.CountryUnitedKingdom, {Value: "XX"}}, listing.Tour.Countries)
+}
+
+func TestUnmarshalFeedJsonLenient(t *testing.T) {
+	feedJson := `{"responseData": [
+		{"catalogBlockSummary": {"blockId": "1", "ticketQuantity": 2}},
+		{"catalogBlockSummary": {"blockId": "2", "ticketQuantity": "two"}},
+		{"catalogBlockSummary": null},
+		{"catalogBlockSummary": {"blockId": "3", "ticketQuantity": 1}}
+	]}`
+
+	// Strict decoding should fail the whole feed
+	_, err := twigots.UnmarshalTwicketsFeedJson([]byte(feedJson))
+	require.Error(t, err)
+
+	// Lenient decoding should only skip the malformed entry
+	listings, decodeErrors, err := twigots.UnmarshalTwicketsFeedJsonLenient([]byte(feedJson))
+	require.NoError(t, err)
+	require.Len(t, listings, 2)
+	require.Equal(t, "1", listings[0].Id)
+	require.Equal(t, "3", listings[1].Id)
+
+	require.Len(t, decodeErrors, 1)
+	require.Equal(t, 1, decodeErrors[0].Index)
+	require.JSONEq(
+		t,
+		`{"catalogBlockSummary": {"blockId": "2", "ticketQuantity": "two"}}`,
+		string(decodeErrors[0].Raw),
+	)
+	require.Error(t, decodeErrors[0].Err)
+
+	// Malformed feeds should still fail
+	_, _, err = twigots.UnmarshalTwicketsFeedJsonLenient([]byte(`{"responseData": {}}`))
+	require.Error(t, err)
+}
+
+func TestListingWithoutNumTickets(t *testing.T) {
+	listings, decodeErrors, err := twigots.UnmarshalTwicketsFeedJsonLenient(
+		[]byte(`{"responseData": [{"catalogBlockSummary": {"blockId": "1"}}]}`),
+	)
+	require.NoError(t, err)
+	require.Empty(t, decodeErrors)
+	require.Len(t, listings, 1)
+
+	// Per ticket prices of listings without a number of tickets should not panic
+	require.NotPanics(t, func() { _ = twigots.TicketListings(listings).Summary() })
+	listing := listings[0]
+	require.Zero(t, listing.TicketPriceInclFee().Amount)
+	require.Zero(t, listing.TicketPriceExclFee().Amount)
+	require.Zero(t, listing.TwicketsFeePerTicket().Amount)
+	require.Zero(t, listing.OriginalTicketPrice().Amount)
+	require.NotPanics(t, func() { _ = listing.Breakdown() })
+}
+
 func TestTicketListingsGetById(t *testing.T) {
 	listings := testTicketListings(t)
 	ticket := listings.GetById("156783487261837")
@@ -68,6 +148,111 @@ func TestTicketListingDiscountString(t *testing.T) {
 	require.Equal(t, "14.41%", discountString)
 }
 
+func TestTicketListingPrices(t *testing.T) {
+	listing := testTicketListings(t)[0]
+	require.Equal(t, "£60.00", listing.TicketPriceExclFee().String())
+	require.Equal(t, "£72.75", listing.TicketPriceInclFee().String())
+	require.Equal(t, "£180.00", listing.TotalPriceExclFee.String())
+	require.Equal(t, "£218.25", listing.TotalPriceInclFee().String())
+	require.Equal(t, "£12.75", listing.TwicketsFeePerTicket().String())
+}
+
+func TestTicketListingBreakdown(t *testing.T) {
+	listings := testTicketListings(t)
+
+	// Foo Fighters is being sold below face value
+	breakdown := listings[0].Breakdown()
+	require.Equal(t, "£85.00", breakdown.PerTicket.FaceValue.String())
+	require.Equal(t, "£60.00", breakdown.PerTicket.SellerPrice.String())
+	require.Equal(t, "£12.75", breakdown.PerTicket.Fee.String())
+	require.Equal(t, "£72.75", breakdown.PerTicket.Price.String())
+	require.Equal(t, "£-12.25", breakdown.PerTicket.Markup.String())
+	require.InDelta(t, 0.2125, breakdown.PerTicket.FeeFraction, 0.0001)
+	require.InDelta(t, -0.1441, breakdown.PerTicket.MarkupFraction, 0.0001)
+
+	require.Equal(t, "£255.00", breakdown.Total.FaceValue.String())
+	require.Equal(t, "£180.00", breakdown.Total.SellerPrice.String())
+	require.Equal(t, "£38.25", breakdown.Total.Fee.String())
+	require.Equal(t, "£218.25", breakdown.Total.Price.String())
+	require.Equal(t, "£-36.75", breakdown.Total.Markup.String())
+	require.InDelta(t, 0.2125, breakdown.Total.FeeFraction, 0.0001)
+	require.InDelta(t, -0.1441, breakdown.Total.MarkupFraction, 0.0001)
+
+	// Mean Girls is being sold at face value, so is marked up by the fee
+	breakdown = listings[1].Breakdown()
+	require.Equal(t, "£65.00", breakdown.PerTicket.FaceValue.String())
+	require.Equal(t, "£9.10", breakdown.PerTicket.Fee.String())
+	require.Equal(t, "£9.10", breakdown.PerTicket.Markup.String())
+	require.InDelta(t, 0.14, breakdown.PerTicket.FeeFraction, 0.0001)
+	require.InDelta(t, 0.14, breakdown.PerTicket.MarkupFraction, 0.0001)
+}
+
+func TestTicketListingBreakdownFeeDrift(t *testing.T) {
+	listings := testTicketListings(t)
+
+	// The fee per ticket reported by the feed for Foo Fighters (£9.00)
+	// does not match its total fee (£38.25 for 3 tickets)
+	breakdown := listings[0].Breakdown()
+	require.True(t, breakdown.HasFeeDrift())
+	require.Equal(t, "£3.75", breakdown.FeeDrift.String())
+
+	// The fees of the other listings should match the feed
+	for _, listing := range listings[1:] {
+		breakdown := listing.Breakdown()
+		require.False(t, breakdown.HasFeeDrift(), listing.Event.Name)
+		require.Equal(t, listing.ReportedTwicketsFeePerTicket, breakdown.PerTicket.Fee, listing.Event.Name)
+		require.Zero(t, breakdown.FeeDrift.Amount, listing.Event.Name)
+	}
+
+	// Rounding should not be reported as drift
+	listing := listings[1]
+	listing.ReportedTwicketsFeePerTicket.Amount++
+	require.False(t, listing.Breakdown().HasFeeDrift())
+
+	// Drift cannot be detected if the feed does not report a fee per ticket
+	listing.ReportedTwicketsFeePerTicket = twigots.Price{}
+	require.False(t, listing.Breakdown().HasFeeDrift())
+}
+
+func TestTicketListingPriceChange(t *testing.T) {
+	listings := testTicketListings(t)
+
+	// Foo Fighters has been reduced from £85 to £60
+	priceChange := listings[0].PriceChange()
+	require.True(t, priceChange.Reduced())
+	require.Equal(t, "£85.00", priceChange.Original.String())
+	require.Equal(t, "£60.00", priceChange.Previous.String())
+	require.Equal(t, "£60.00", priceChange.Current.String())
+	require.Equal(t, "£25.00", priceChange.Reduction().String())
+	require.InDelta(t, 0.294, priceChange.ReductionFraction(), 0.001)
+
+	// Mean Girls has not been reduced
+	priceChange = listings[1].PriceChange()
+	require.False(t, priceChange.Reduced())
+	require.Equal(t, "£0.00", priceChange.Reduction().String())
+	require.Zero(t, priceChange.ReductionFraction())
+
+	// Listing without original selling price is unchanged
+	listing := listings[1]
+	listing.OriginalSellingPrice = twigots.Price{}
+	listing.PreviousSellingPrice = twigots.Price{}
+	priceChange = listing.PriceChange()
+	require.False(t, priceChange.Reduced())
+	require.Equal(t, "£65.00", priceChange.Original.String())
+}
+
+func TestTicketListingJSONRoundTrip(t *testing.T) {
+	listings := testTicketListings(t)
+
+	listingsJson, err := json.Marshal(listings)
+	require.NoError(t, err)
+
+	var unmarshalledListings twigots.TicketListings
+	err = json.Unmarshal(listingsJson, &unmarshalledListings)
+	require.NoError(t, err)
+	require.Equal(t, listings, unmarshalledListings)
+}
+
 func testTicketListings(t *testing.T) twigots.TicketListings {
 	projectDirectory := testutils.ProjectDirectory(t)
 	feedJsonFilePath := filepath.Join(projectDirectory, "test", "data", "fullFeedResponse.json")
@@ -0,0 +1,178 @@
+package twigots
+
+import (
+	"cmp"
+	"slices"
+)
+
+// TicketListingComparator compares two ticket listings, returning a negative number if a
+// should be ordered before b, a positive number if a should be ordered after b, and zero if
+// their order does not matter.
+type TicketListingComparator func(a, b TicketListing) int
+
+// CompareByPrice orders ticket listings by the price of a single ticket (including fee),
+// from cheapest to most expensive.
+//
+// Prices in different currencies cannot be compared, so listings are grouped by currency
+// (see Price.Cmp). Use CompareByPriceIn to order listings in different currencies by price.
+func CompareByPrice(a, b TicketListing) int {
+	return a.TicketPriceInclFee().Cmp(b.TicketPriceInclFee())
+}
+
+// CompareByPriceIn orders ticket listings by the price of a single ticket (including fee) in a currency,
+// from cheapest to most expensive. Prices in a different currency are converted using the converter.
+//
+// Listings whose prices cannot be converted are ordered after all other listings, grouped by currency.
+func CompareByPriceIn(currency Currency, converter Converter) TicketListingComparator {
+	return func(a, b TicketListing) int {
+		aPrice, aErr := converter.Convert(a.TicketPriceInclFee(), currency)
+		bPrice, bErr := converter.Convert(b.TicketPriceInclFee(), currency)
+		switch {
+		case aErr != nil && bErr != nil:
+			return CompareByPrice(a, b)
+		case aErr != nil:
+			return 1
+		case bErr != nil:
+			return -1
+		default:
+			return aPrice.Cmp(bPrice)
+		}
+	}
+}
+
+// CompareByDiscount orders ticket listings by discount, from smallest to largest discount.
+// Use Reverse(CompareByDiscount) to order from best to worst discount.
+func CompareByDiscount(a, b TicketListing) int {
+	return cmp.Compare(a.Discount(), b.Discount())
+}
+
+// CompareByCreatedAt orders ticket listings by the time they were created, from oldest to newest.
+// Use Reverse(CompareByCreatedAt) to order from newest to oldest (the same order as the feed).
+func CompareByCreatedAt(a, b TicketListing) int {
+	return a.CreatedAt.Compare(b.CreatedAt.Time)
+}
+
+// CompareByEventDate orders ticket listings by the start time of their event, from soonest to latest.
+func CompareByEventDate(a, b TicketListing) int {
+	return a.Event.StartTime().Compare(b.Event.StartTime())
+}
+
+// Reverse reverses the order of a comparator.
+func Reverse(comparator TicketListingComparator) TicketListingComparator {
+	return func(a, b TicketListing) int {
+		return comparator(b, a)
+	}
+}
+
+// SortBy returns a sorted copy of the ticket listings. The original listings are not modified.
+//
+// Listings are ordered by the first comparator, with any subsequent comparators used to break ties.
+// Listings that are still tied keep their original order.
+func (l TicketListings) SortBy(comparators ...TicketListingComparator) TicketListings {
+	sortedListings := slices.Clone(l)
+	slices.SortStableFunc(sortedListings, func(a, b TicketListing) int {
+		for _, comparator := range comparators {
+			result := comparator(a, b)
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	})
+	return sortedListings
+}
+
+// GroupByEvent groups ticket listings by their event id.
+// Listings within each group keep their original order.
+func (l TicketListings) GroupByEvent() map[string]TicketListings {
+	return l.groupBy(func(listing TicketListing) string { return listing.Event.Id })
+}
+
+// GroupByTour groups ticket listings by their tour id.
+// Listings within each group keep their original order.
+func (l TicketListings) GroupByTour() map[string]TicketListings {
+	return l.groupBy(func(listing TicketListing) string { return listing.Tour.Id })
+}
+
+// GroupByVenue groups ticket listings by their event venue id.
+// Listings within each group keep their original order.
+func (l TicketListings) GroupByVenue() map[string]TicketListings {
+	return l.groupBy(func(listing TicketListing) string { return listing.Event.Venue.Id })
+}
+
+func (l TicketListings) groupBy(key func(TicketListing) string) map[string]TicketListings {
+	groups := make(map[string]TicketListings)
+	for _, listing := range l {
+		groupKey := key(listing)
+		groups[groupKey] = append(groups[groupKey], listing)
+	}
+	return groups
+}
+
+// Events gets the distinct events of the ticket listings, in order of first appearance.
+func (l TicketListings) Events() []Event {
+	events := make([]Event, 0, len(l))
+	seenEventIds := make(map[string]struct{}, len(l))
+	for _, listing := range l {
+		if _, ok := seenEventIds[listing.Event.Id]; ok {
+			continue
+		}
+		seenEventIds[listing.Event.Id] = struct{}{}
+		events = append(events, listing.Event)
+	}
+	return events
+}
+
+// TicketListingsSummary contains aggregate statistics of a set of ticket listings.
+type TicketListingsSummary struct {
+	// NumListings is the number of ticket listings.
+	NumListings int
+
+	// NumTickets is the total number of tickets available across all listings.
+	NumTickets int
+
+	// Minimum, median and maximum price of a single ticket, including fee.
+	MinTicketPriceInclFee    Price
+	MedianTicketPriceInclFee Price
+	MaxTicketPriceInclFee    Price
+
+	// MeanDiscount is the mean discount across all listings.
+	// See TicketListing.Discount for details.
+	MeanDiscount float64
+}
+
+// Summary calculates aggregate statistics of the ticket listings.
+//
+// Prices are compared by amount only, so listings are expected to share a currency.
+// If there are no listings, an empty summary is returned.
+func (l TicketListings) Summary() TicketListingsSummary {
+	if len(l) == 0 {
+		return TicketListingsSummary{}
+	}
+
+	ticketPrices := make([]Price, 0, len(l))
+	var numTickets int
+	var discountSum float64
+	for _, listing := range l {
+		ticketPrices = append(ticketPrices, listing.TicketPriceInclFee())
+		numTickets += listing.NumTickets
+		discountSum += listing.Discount()
+	}
+
+	slices.SortFunc(ticketPrices, Price.Cmp)
+
+	medianTicketPrice := ticketPrices[len(ticketPrices)/2]
+	if len(ticketPrices)%2 == 0 {
+		lowerMedianTicketPrice := ticketPrices[len(ticketPrices)/2-1]
+		medianTicketPrice = lowerMedianTicketPrice.Add(medianTicketPrice).Divide(2)
+	}
+
+	return TicketListingsSummary{
+		NumListings:              len(l),
+		NumTickets:               numTickets,
+		MinTicketPriceInclFee:    ticketPrices[0],
+		MedianTicketPriceInclFee: medianTicketPrice,
+		MaxTicketPriceInclFee:    ticketPrices[len(ticketPrices)-1],
+		MeanDiscount:             discountSum / float64(len(l)),
+	}
+}
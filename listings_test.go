@@ -0,0 +1,90 @@
+package twigots_test
+
+import (
+	"testing"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicketListingsSortBy(t *testing.T) {
+	listings := testTicketListings(t)
+
+	sortedListings := listings.SortBy(twigots.CompareByPrice)
+	require.Equal(t, "Foo Fighters", sortedListings[0].Event.Name)
+	require.Equal(t, "Mean Girls", sortedListings[1].Event.Name)
+	require.Equal(t, "South Africa v Wales", sortedListings[2].Event.Name)
+	require.Equal(t, "Download Festival 2024", sortedListings[3].Event.Name)
+
+	// Original listings should not be modified
+	require.Equal(t, "Foo Fighters", listings[0].Event.Name)
+	require.Equal(t, "Mean Girls", listings[1].Event.Name)
+
+	sortedListings = listings.SortBy(twigots.Reverse(twigots.CompareByCreatedAt))
+	require.Equal(t, "Download Festival 2024", sortedListings[0].Event.Name)
+	require.Equal(t, "Foo Fighters", sortedListings[1].Event.Name)
+	require.Equal(t, "Mean Girls", sortedListings[2].Event.Name)
+	require.Equal(t, "South Africa v Wales", sortedListings[3].Event.Name)
+
+	sortedListings = listings.SortBy(twigots.CompareByEventDate)
+	require.Equal(t, "Download Festival 2024", sortedListings[0].Event.Name)
+
+	// Discount ties (no discount) should be broken by price
+	sortedListings = listings.SortBy(twigots.Reverse(twigots.CompareByDiscount), twigots.CompareByPrice)
+	require.Equal(t, "Foo Fighters", sortedListings[0].Event.Name)
+}
+
+func TestCompareByPriceIn(t *testing.T) {
+	listing := func(id string, currency twigots.Currency, amount int64) twigots.TicketListing {
+		return twigots.TicketListing{
+			Id:                id,
+			NumTickets:        1,
+			TotalPriceExclFee: twigots.Price{Currency: currency, Amount: amount},
+			TwicketsFee:       twigots.Price{Currency: currency},
+		}
+	}
+	listings := twigots.TicketListings{
+		listing("1", twigots.CurrencyEUR, 3000), // £24
+		listing("2", twigots.CurrencyGBP, 2000), // £20
+		listing("3", twigots.CurrencySEK, 1000), // No rate
+		listing("4", twigots.CurrencyUSD, 3300), // £22
+	}
+
+	// Prices should be compared in the same currency, with prices that cannot be converted last
+	sortedListings := listings.SortBy(twigots.CompareByPriceIn(twigots.CurrencyGBP, testRates))
+	ids := make([]string, 0, len(sortedListings))
+	for _, listing := range sortedListings {
+		ids = append(ids, listing.Id)
+	}
+	require.Equal(t, []string{"2", "4", "1", "3"}, ids)
+}
+
+func TestTicketListingsGroupBy(t *testing.T) {
+	listings := testTicketListings(t)
+
+	eventGroups := listings.GroupByEvent()
+	require.Len(t, eventGroups, 4)
+	require.Len(t, eventGroups[listings[0].Event.Id], 1)
+
+	// Foo Fighters and Mean Girls are both in London but at different venues
+	venueGroups := listings.GroupByVenue()
+	require.Len(t, venueGroups, 4)
+
+	events := append(listings, listings...).Events()
+	require.Len(t, events, 4)
+	require.Equal(t, "Foo Fighters", events[0].Name)
+}
+
+func TestTicketListingsSummary(t *testing.T) {
+	listings := testTicketListings(t)
+
+	summary := listings.Summary()
+	require.Equal(t, 4, summary.NumListings)
+	require.Equal(t, 10, summary.NumTickets)
+	require.Equal(t, "£72.75", summary.MinTicketPriceInclFee.String())
+	require.Equal(t, "£91.20", summary.MedianTicketPriceInclFee.String())
+	require.Equal(t, "£310.80", summary.MaxTicketPriceInclFee.String())
+	require.InDelta(t, -0.0253, summary.MeanDiscount, 0.001)
+
+	require.Equal(t, twigots.TicketListingsSummary{}, twigots.TicketListings{}.Summary())
+}
@@ -3,6 +3,7 @@ package twigots
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/orsinium-labs/enum"
 )
@@ -10,13 +11,80 @@ import (
 var (
 	country = enum.NewBuilder[string, Country]()
 
+	CountryAustria       = country.Add(Country{"AT"})
+	CountryBelgium       = country.Add(Country{"BE"})
+	CountryCzechRepublic = country.Add(Country{"CZ"})
+	CountryDenmark       = country.Add(Country{"DK"})
+	CountryFinland       = country.Add(Country{"FI"})
+	CountryFrance        = country.Add(Country{"FR"})
+	CountryGermany       = country.Add(Country{"DE"})
+	CountryIreland       = country.Add(Country{"IE"})
+	CountryItaly         = country.Add(Country{"IT"})
+	CountryLuxembourg    = country.Add(Country{"LU"})
+	CountryNetherlands   = country.Add(Country{"NL"})
+	CountryNorway        = country.Add(Country{"NO"})
+	CountryPoland        = country.Add(Country{"PL"})
+	CountryPortugal      = country.Add(Country{"PT"})
+	CountrySpain         = country.Add(Country{"ES"})
+	CountrySweden        = country.Add(Country{"SE"})
+	CountrySwitzerland   = country.Add(Country{"CH"})
 	CountryUnitedKingdom = country.Add(Country{"GB"})
+	CountryUnitedStates  = country.Add(Country{"US"})
 
 	Countries = country.Enum()
 )
 
+// Country is a country that Twickets operates in.
+//
+// Countries decoded from the ticket listings feed may not be one of Countries,
+// if Twickets has started operating in a new country. Use Countries.Contains to check.
 type Country enum.Member[string]
 
+// Regions gets the known regions within a country.
+//
+// Regions are only provided for the United Kingdom, so no regions are returned for any other country.
+// Regions of other countries are not supported, as their codes are not published by Twickets.
+// This does not mean other countries have no regions: listings in other countries keep whatever
+// region code the feed returns (see Location), and Region.Country works for these unknown regions
+// as region codes are prefixed with their country code.
+func (c Country) Regions() []Region {
+	var regions []Region
+	for _, region := range Regions.Members() {
+		if region.Country() == c {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// Currency gets the currency tickets are sold in within the country.
+// Returns an unset currency if the country is not valid.
+func (c Country) Currency() Currency {
+	switch c {
+	case CountryUnitedKingdom:
+		return CurrencyGBP
+	case CountryUnitedStates:
+		return CurrencyUSD
+	case CountrySwitzerland:
+		return CurrencyCHF
+	case CountrySweden:
+		return CurrencySEK
+	case CountryDenmark:
+		return CurrencyDKK
+	case CountryNorway:
+		return CurrencyNOK
+	case CountryPoland:
+		return CurrencyPLN
+	case CountryCzechRepublic:
+		return CurrencyCZK
+	default:
+		if Countries.Contains(c) {
+			return CurrencyEUR
+		}
+		return Currency{}
+	}
+}
+
 func (c *Country) UnmarshalJSON(data []byte) error {
 	var countryString string
 	err := json.Unmarshal(data, &countryString)
@@ -24,6 +92,12 @@ func (c *Country) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// An empty country is unset
+	if countryString == "" {
+		*c = Country{}
+		return nil
+	}
+
 	country := Countries.Parse(countryString)
 	if country == nil {
 		return fmt.Errorf("country '%s' is not valid", countryString)
@@ -48,6 +122,11 @@ func (c *Country) UnmarshalText(data []byte) error {
 	return nil
 }
 
+// Region is a region within a country e.g. London.
+// Only the regions of the United Kingdom are currently included in Regions. See Country.Regions.
+//
+// Regions decoded from the ticket listings feed may not be one of Regions,
+// if Twickets has added new regions. Use Regions.Contains to check.
 type Region enum.Member[string]
 
 func (r Region) MarshalJSON() ([]byte, error) {
@@ -61,6 +140,12 @@ func (r *Region) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// An empty region is unset
+	if regionString == "" {
+		*r = Region{}
+		return nil
+	}
+
 	region := Regions.Parse(regionString)
 	if region == nil {
 		return fmt.Errorf("region '%s' is not valid", regionString)
@@ -70,6 +155,18 @@ func (r *Region) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Country gets the country a region is in.
+// Returns an unset country if the region is not in a valid country.
+func (r Region) Country() Country {
+	// Region codes are prefixed with their country code
+	for _, country := range Countries.Members() {
+		if strings.HasPrefix(r.Value, country.Value) {
+			return country
+		}
+	}
+	return Country{}
+}
+
 func (r *Region) UnmarshalText(data []byte) error {
 	regionString := string(data)
 	region := Regions.Parse(regionString)
@@ -81,6 +178,8 @@ func (r *Region) UnmarshalText(data []byte) error {
 	return nil
 }
 
+// Region codes are prefixed with the code of the country they are in.
+// Only the regions of the United Kingdom are supported. See Country.Regions.
 var (
 	region = enum.NewBuilder[string, Region]()
 
@@ -18,3 +18,35 @@ func TestRegionMarshalJSON(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `"GBLO"`, string(data))
 }
+
+func TestRegionCountry(t *testing.T) {
+	require.Equal(t, CountryUnitedKingdom, RegionLondon.Country())
+	require.Equal(t, Country{}, Region{"XXXX"}.Country())
+}
+
+func TestCountryRegions(t *testing.T) {
+	require.Len(t, CountryUnitedKingdom.Regions(), 12)
+
+	// Regions of other countries are not known
+	for _, country := range Countries.Members() {
+		if country != CountryUnitedKingdom {
+			require.Empty(t, country.Regions(), country.Value)
+		}
+	}
+}
+
+func TestLocationUnknownRegion(t *testing.T) {
+	var location Location
+	err := json.Unmarshal([]byte(`{"countryCode": "IE", "regionCode": "IEDU"}`), &location)
+	require.NoError(t, err)
+	require.Equal(t, Region{"IEDU"}, location.Region)
+	require.False(t, Regions.Contains(location.Region))
+	require.Equal(t, CountryIreland, location.Region.Country())
+}
+
+func TestCountryCurrency(t *testing.T) {
+	require.Equal(t, CurrencyGBP, CountryUnitedKingdom.Currency())
+	require.Equal(t, CurrencyEUR, CountryIreland.Currency())
+	require.Equal(t, CurrencyUSD, CountryUnitedStates.Currency())
+	require.Equal(t, Currency{}, Country{"XX"}.Currency())
+}
@@ -0,0 +1,121 @@
+// Package prometheus records twigots client metrics using Prometheus.
+//
+// Usage:
+//
+//	registry := prom.NewRegistry()
+//	metrics, err := prometheus.New(registry)
+//	client, err := twigots.NewClient(apiKey, twigots.WithMetrics(metrics))
+package prometheus
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ahobsonsayers/twigots"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "twigots"
+
+// Metrics records twigots client metrics using Prometheus. It implements twigots.Metrics.
+//
+// The following metrics are recorded:
+//   - twigots_feed_requests_total: Feed page requests, by status code ("error" if no response was received)
+//   - twigots_feed_request_duration_seconds: Feed page request latency, by status code
+//   - twigots_feed_decode_errors_total: Feed entries skipped as they failed to decode (see twigots.WithLenientDecoding)
+//   - twigots_fetches_total: Calls to FetchTicketListings, by result ("success" or "error")
+//   - twigots_fetch_pages: Number of feed pages requested per call to FetchTicketListings
+//   - twigots_listings_total: Ticket listings fetched, by event venue region
+type Metrics struct {
+	feedRequests        *prom.CounterVec
+	feedRequestDuration *prom.HistogramVec
+	feedDecodeErrors    prom.Counter
+	fetches             *prom.CounterVec
+	fetchPages          prom.Histogram
+	listings            *prom.CounterVec
+}
+
+var _ twigots.Metrics = (*Metrics)(nil)
+
+// New creates metrics, registering them with the registerer.
+func New(registerer prom.Registerer) (*Metrics, error) {
+	if registerer == nil {
+		return nil, errors.New("registerer must be set")
+	}
+
+	metrics := &Metrics{
+		feedRequests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_requests_total",
+			Help:      "Number of feed page requests, by status code.",
+		}, []string{"status"}),
+		feedRequestDuration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "feed_request_duration_seconds",
+			Help:      "Latency of feed page requests, by status code.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"status"}),
+		feedDecodeErrors: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_decode_errors_total",
+			Help:      "Number of feed entries skipped as they failed to decode.",
+		}),
+		fetches: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "fetches_total",
+			Help:      "Number of calls to fetch ticket listings, by result.",
+		}, []string{"result"}),
+		fetchPages: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "fetch_pages",
+			Help:      "Number of feed pages requested per call to fetch ticket listings.",
+			Buckets:   []float64{1, 2, 3, 5, 10, 25, 50, 100},
+		}),
+		listings: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "listings_total",
+			Help:      "Number of ticket listings fetched, by event venue region.",
+		}, []string{"region"}),
+	}
+
+	for _, collector := range []prom.Collector{
+		metrics.feedRequests,
+		metrics.feedRequestDuration,
+		metrics.feedDecodeErrors,
+		metrics.fetches,
+		metrics.fetchPages,
+		metrics.listings,
+	} {
+		err := registerer.Register(collector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return metrics, nil
+}
+
+func (m *Metrics) ObserveFeedRequest(_ twigots.FeedRequest, response twigots.FeedResponse) {
+	status := "error"
+	if response.StatusCode != 0 {
+		status = strconv.Itoa(response.StatusCode)
+	}
+
+	m.feedRequests.WithLabelValues(status).Inc()
+	m.feedRequestDuration.WithLabelValues(status).Observe(response.Duration.Seconds())
+	m.feedDecodeErrors.Add(float64(response.NumDecodeErrors))
+}
+
+func (m *Metrics) ObserveFetch(result twigots.FetchResult) {
+	if result.Err != nil {
+		m.fetches.WithLabelValues("error").Inc()
+	} else {
+		m.fetches.WithLabelValues("success").Inc()
+	}
+
+	m.fetchPages.Observe(float64(result.NumPages))
+
+	for _, listing := range result.Listings {
+		m.listings.WithLabelValues(listing.Event.Venue.Location.Region.Value).Inc()
+	}
+}
@@ -0,0 +1,70 @@
+package prometheus
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prom.NewRegistry()
+	metrics, err := New(registry)
+	require.NoError(t, err)
+
+	metrics.ObserveFeedRequest(twigots.FeedRequest{}, twigots.FeedResponse{StatusCode: 200, Duration: time.Second})
+	metrics.ObserveFeedRequest(twigots.FeedRequest{}, twigots.FeedResponse{
+		StatusCode:      200,
+		Duration:        time.Second,
+		NumDecodeErrors: 3,
+	})
+	metrics.ObserveFeedRequest(twigots.FeedRequest{}, twigots.FeedResponse{StatusCode: 403})
+	metrics.ObserveFeedRequest(twigots.FeedRequest{}, twigots.FeedResponse{Err: errors.New("timeout")})
+
+	londonListing := twigots.TicketListing{}
+	londonListing.Event.Venue.Location.Region = twigots.RegionLondon
+	metrics.ObserveFetch(twigots.FetchResult{
+		NumPages: 2,
+		Listings: twigots.TicketListings{londonListing, londonListing},
+	})
+	metrics.ObserveFetch(twigots.FetchResult{NumPages: 1, Err: errors.New("forbidden")})
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP twigots_feed_decode_errors_total Number of feed entries skipped as they failed to decode.
+# TYPE twigots_feed_decode_errors_total counter
+twigots_feed_decode_errors_total 3
+# HELP twigots_feed_requests_total Number of feed page requests, by status code.
+# TYPE twigots_feed_requests_total counter
+twigots_feed_requests_total{status="200"} 2
+twigots_feed_requests_total{status="403"} 1
+twigots_feed_requests_total{status="error"} 1
+# HELP twigots_fetches_total Number of calls to fetch ticket listings, by result.
+# TYPE twigots_fetches_total counter
+twigots_fetches_total{result="error"} 1
+twigots_fetches_total{result="success"} 1
+# HELP twigots_listings_total Number of ticket listings fetched, by event venue region.
+# TYPE twigots_listings_total counter
+twigots_listings_total{region="GBLO"} 2
+`),
+		"twigots_feed_decode_errors_total", "twigots_feed_requests_total",
+		"twigots_fetches_total", "twigots_listings_total",
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, testutil.CollectAndCount(metrics.fetchPages))
+}
+
+func TestNewDuplicateRegistration(t *testing.T) {
+	registry := prom.NewRegistry()
+	_, err := New(registry)
+	require.NoError(t, err)
+
+	// Registering twice with the same registry should fail, rather than panic
+	_, err = New(registry)
+	require.Error(t, err)
+}
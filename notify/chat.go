@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// messageSink is a sink that formats listings as messages, and sends each message using a send function.
+type messageSink struct {
+	formatter *Formatter
+	send      func(ctx context.Context, message Message) error
+}
+
+func (s messageSink) Send(ctx context.Context, listings ...twigots.TicketListing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	messages, err := s.formatter.Format(listings...)
+	if err != nil {
+		return err
+	}
+
+	for idx, message := range messages {
+		err := s.send(ctx, message)
+		if err == nil {
+			continue
+		}
+		if idx == 0 {
+			return err
+		}
+
+		// Report which listings were not sent, so they can be retried without resending the others
+		var unsent []twigots.TicketListing
+		for _, unsentMessage := range messages[idx:] {
+			unsent = append(unsent, unsentMessage.Listings...)
+		}
+		return &PartialSendError{Unsent: unsent, Err: err}
+	}
+	return nil
+}
+
+func newMessageSink(formatter *Formatter, send func(context.Context, Message) error) messageSink {
+	if formatter == nil {
+		formatter = DefaultFormatter()
+	}
+	return messageSink{
+		formatter: formatter,
+		send:      send,
+	}
+}
+
+// NewSlackSink creates a sink that sends messages to a Slack incoming webhook.
+// See: https://api.slack.com/messaging/webhooks
+//
+// If formatter is nil, DefaultFormatter is used.
+// If client is nil, a client with a timeout of DefaultTimeout is used.
+func NewSlackSink(webhookURL string, formatter *Formatter, client *http.Client) (Sink, error) {
+	if webhookURL == "" {
+		return nil, errors.New("webhook url must be set")
+	}
+	if client == nil {
+		client = defaultClient
+	}
+
+	return newMessageSink(formatter, func(ctx context.Context, message Message) error {
+		text := message.Body
+		if message.Title != "" {
+			text = fmt.Sprintf("*%s*\n%s", message.Title, message.Body)
+		}
+
+		payload := map[string]any{"text": text}
+		return postJSON(ctx, client, webhookURL, nil, payload)
+	}), nil
+}
+
+// Maximum length of a discord message
+const discordMaxContentLength = 2000
+
+// NewDiscordSink creates a sink that sends messages to a Discord webhook.
+// See: https://discord.com/developers/docs/resources/webhook#execute-webhook
+//
+// If formatter is nil, DefaultFormatter is used.
+// If client is nil, a client with a timeout of DefaultTimeout is used.
+func NewDiscordSink(webhookURL string, formatter *Formatter, client *http.Client) (Sink, error) {
+	if webhookURL == "" {
+		return nil, errors.New("webhook url must be set")
+	}
+	if client == nil {
+		client = defaultClient
+	}
+
+	return newMessageSink(formatter, func(ctx context.Context, message Message) error {
+		content := message.Body
+		if message.Title != "" {
+			content = fmt.Sprintf("**%s**\n%s", message.Title, message.Body)
+		}
+		if utf8.RuneCountInString(content) > discordMaxContentLength {
+			content = string([]rune(content)[:discordMaxContentLength-3]) + "..."
+		}
+
+		payload := map[string]any{"content": content}
+		return postJSON(ctx, client, webhookURL, nil, payload)
+	}), nil
+}
+
+// NewNtfySink creates a sink that publishes messages to an ntfy topic.
+// topicURL is the full url of the topic e.g. https://ntfy.sh/my-topic
+// See: https://docs.ntfy.sh/publish/
+//
+// If formatter is nil, DefaultFormatter is used.
+// If client is nil, a client with a timeout of DefaultTimeout is used.
+func NewNtfySink(topicURL string, formatter *Formatter, client *http.Client) (Sink, error) {
+	if topicURL == "" {
+		return nil, errors.New("topic url must be set")
+	}
+	if client == nil {
+		client = defaultClient
+	}
+
+	return newMessageSink(formatter, func(ctx context.Context, message Message) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, topicURL, strings.NewReader(message.Body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		request.Header.Set("Tags", "ticket")
+		if message.Title != "" {
+			request.Header.Set("Title", message.Title)
+		}
+		if message.URL != "" {
+			request.Header.Set("Click", message.URL)
+		}
+
+		return doRequest(client, request)
+	}), nil
+}
+
+// NewGotifySink creates a sink that sends messages to a Gotify server, using an application token.
+// serverURL is the base url of the server e.g. https://gotify.example.com
+// See: https://gotify.net/docs/pushmsg
+//
+// If formatter is nil, DefaultFormatter is used.
+// If client is nil, a client with a timeout of DefaultTimeout is used.
+func NewGotifySink(serverURL, token string, formatter *Formatter, client *http.Client) (Sink, error) {
+	if serverURL == "" {
+		return nil, errors.New("server url must be set")
+	}
+	if token == "" {
+		return nil, errors.New("token must be set")
+	}
+	if client == nil {
+		client = defaultClient
+	}
+
+	messageURL, err := url.JoinPath(serverURL, "message")
+	if err != nil {
+		return nil, fmt.Errorf("invalid server url: %w", err)
+	}
+
+	return newMessageSink(formatter, func(ctx context.Context, message Message) error {
+		payload := map[string]any{
+			"title":    message.Title,
+			"message":  message.Body,
+			"priority": 5,
+		}
+		if message.URL != "" {
+			payload["extras"] = map[string]any{
+				"client::notification": map[string]any{
+					"click": map[string]any{"url": message.URL},
+				},
+			}
+		}
+
+		headers := map[string]string{"X-Gotify-Key": token}
+		return postJSON(ctx, client, messageURL, headers, payload)
+	}), nil
+}
@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testServer creates a server that records the requests it receives.
+func testServer(t *testing.T) (*httptest.Server, *[]*http.Request, *[]string) {
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &bodies
+}
+
+func TestSlackSink(t *testing.T) {
+	server, requests, bodies := testServer(t)
+
+	sink, err := NewSlackSink(server.URL, nil, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+
+	var payload map[string]string
+	err = json.Unmarshal([]byte((*bodies)[0]), &payload)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"*Coldplay*\nColdplay - 2 tickets at £55.00 each https://www.twickets.live/app/block/1,2",
+		payload["text"],
+	)
+}
+
+func TestDiscordSink(t *testing.T) {
+	server, requests, bodies := testServer(t)
+
+	formatter, err := NewFormatter("", "", 10)
+	require.NoError(t, err)
+	sink, err := NewDiscordSink(server.URL, formatter, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(
+		context.Background(),
+		testListing("1", "Coldplay", 2, 100),
+		testListing("2", "Hamilton", 1, 50),
+	)
+	require.NoError(t, err)
+	require.Len(t, *requests, 1) // Batched
+
+	var payload map[string]string
+	err = json.Unmarshal([]byte((*bodies)[0]), &payload)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"**2 new ticket listings**\n"+
+			"Coldplay - 2 tickets at £55.00 each https://www.twickets.live/app/block/1,2\n"+
+			"Hamilton - 1 ticket at £55.00 each https://www.twickets.live/app/block/2,1",
+		payload["content"],
+	)
+}
+
+func TestDiscordSinkTruncation(t *testing.T) {
+	server, _, bodies := testServer(t)
+
+	// Body of multi-byte characters, longer than the maximum content length
+	formatter, err := NewFormatter("", strings.Repeat("£", 3000), 1)
+	require.NoError(t, err)
+	sink, err := NewDiscordSink(server.URL, formatter, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+
+	var payload map[string]string
+	err = json.Unmarshal([]byte((*bodies)[0]), &payload)
+	require.NoError(t, err)
+	require.True(t, utf8.ValidString(payload["content"]))
+	require.Equal(t, discordMaxContentLength, utf8.RuneCountInString(payload["content"]))
+	require.True(t, strings.HasSuffix(payload["content"], "£..."))
+}
+
+func TestNtfySink(t *testing.T) {
+	server, requests, bodies := testServer(t)
+
+	sink, err := NewNtfySink(server.URL+"/twickets", nil, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+
+	request := (*requests)[0]
+	require.Equal(t, "/twickets", request.URL.Path)
+	require.Equal(t, "Coldplay", request.Header.Get("Title"))
+	require.Equal(t, "https://www.twickets.live/app/block/1,2", request.Header.Get("Click"))
+	require.Equal(t, "Coldplay - 2 tickets at £55.00 each https://www.twickets.live/app/block/1,2", (*bodies)[0])
+}
+
+func TestGotifySink(t *testing.T) {
+	server, requests, bodies := testServer(t)
+
+	sink, err := NewGotifySink(server.URL, "token", nil, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	require.Len(t, *requests, 1)
+
+	request := (*requests)[0]
+	require.Equal(t, "/message", request.URL.Path)
+	require.Equal(t, "token", request.Header.Get("X-Gotify-Key"))
+
+	var payload struct {
+		Title   string `json:"title"`
+		Message string `json:"message"`
+		Extras  struct {
+			Notification struct {
+				Click struct {
+					URL string `json:"url"`
+				} `json:"click"`
+			} `json:"client::notification"`
+		} `json:"extras"`
+	}
+	err = json.Unmarshal([]byte((*bodies)[0]), &payload)
+	require.NoError(t, err)
+	require.Equal(t, "Coldplay", payload.Title)
+	require.Equal(t, "https://www.twickets.live/app/block/1,2", payload.Extras.Notification.Click.URL)
+
+	_, err = NewGotifySink(server.URL, "", nil, nil)
+	require.ErrorContains(t, err, "token must be set")
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+)
+
+// SinkType is the type of a sink.
+type SinkType string
+
+const (
+	SinkTypeStdout  SinkType = "stdout"
+	SinkTypeFile    SinkType = "file"
+	SinkTypeExec    SinkType = "exec"
+	SinkTypeWebhook SinkType = "webhook"
+	SinkTypeSlack   SinkType = "slack"
+	SinkTypeDiscord SinkType = "discord"
+	SinkTypeNtfy    SinkType = "ntfy"
+	SinkTypeGotify  SinkType = "gotify"
+	SinkTypeEmail   SinkType = "email"
+)
+
+// SinkConfig is the configuration of a sink.
+// It can be used to create sinks from configuration files.
+type SinkConfig struct {
+	Type SinkType `json:"type"`
+
+	// Path of the file to append listings to. Used by file sinks.
+	Path string `json:"path,omitempty"`
+
+	// Command (and arguments) to run for each listing. Used by exec sinks.
+	Command []string `json:"command,omitempty"`
+
+	// URL to send notifications to. Used by webhook, slack, discord, ntfy and gotify sinks.
+	// For ntfy sinks this is the topic url. For gotify sinks this is the server url.
+	URL string `json:"url,omitempty"`
+
+	// Headers to set on requests. Used by webhook sinks.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Token used to authenticate. Used by gotify sinks.
+	Token string `json:"token,omitempty"`
+
+	// Templates used to format message titles and bodies. See Formatter.
+	// Used by slack, discord, ntfy and gotify sinks. Defaults to DefaultTitleTemplate and DefaultBodyTemplate.
+	TitleTemplate string `json:"titleTemplate,omitempty"`
+	BodyTemplate  string `json:"bodyTemplate,omitempty"`
+
+	// BatchSize is the maximum number of listings included in a single message. See Formatter.
+	// Used by slack, discord, ntfy and gotify sinks. Defaults to a message per listing.
+	BatchSize int `json:"batchSize,omitempty"`
+
+	// Email is the configuration of email sinks.
+	Email *EmailConfig `json:"email,omitempty"`
+
+	// MaxAttempts is the maximum number of attempts made to send notifications. See RetrySink.
+	// Defaults to 1 (no retries).
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+}
+
+// Sink creates the sink described by the config.
+func (c SinkConfig) Sink() (Sink, error) {
+	sink, err := c.sink()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.MaxAttempts > 1 {
+		sink = RetrySink(sink, c.MaxAttempts, DefaultRetryDelay)
+	}
+
+	return sink, nil
+}
+
+func (c SinkConfig) sink() (Sink, error) {
+	switch c.Type {
+	case SinkTypeStdout:
+		return NewTextSink(os.Stdout), nil
+	case SinkTypeFile:
+		if c.Path == "" {
+			return nil, fmt.Errorf("%s sink path must be set", c.Type)
+		}
+		return NewFileSink(c.Path), nil
+	case SinkTypeExec:
+		return NewExecSink(c.Command...)
+	case SinkTypeWebhook:
+		return NewWebhookSink(c.URL, c.Headers, nil)
+	case SinkTypeEmail:
+		if c.Email == nil {
+			return nil, fmt.Errorf("%s sink email config must be set", c.Type)
+		}
+		return NewEmailSink(*c.Email)
+	}
+
+	formatter, err := NewFormatter(c.TitleTemplate, c.BodyTemplate, c.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Type {
+	case SinkTypeSlack:
+		return NewSlackSink(c.URL, formatter, nil)
+	case SinkTypeDiscord:
+		return NewDiscordSink(c.URL, formatter, nil)
+	case SinkTypeNtfy:
+		return NewNtfySink(c.URL, formatter, nil)
+	case SinkTypeGotify:
+		return NewGotifySink(c.URL, c.Token, formatter, nil)
+	default:
+		return nil, fmt.Errorf("sink type '%s' is not valid", c.Type)
+	}
+}
+
+// Sinks creates the sinks described by each of the configs.
+func Sinks(configs ...SinkConfig) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(configs))
+	for i, config := range configs {
+		sink, err := config.Sink()
+		if err != nil {
+			return nil, fmt.Errorf("invalid sink %d: %w", i+1, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
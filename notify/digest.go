@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// DigestSink is a sink that buffers listings, and periodically sends all buffered listings
+// to another sink at once. This is useful for sending periodic digests e.g. with an EmailSink
+// configured to send digests.
+type DigestSink struct {
+	sink     Sink
+	interval time.Duration
+
+	mutex    sync.Mutex
+	listings []twigots.TicketListing
+}
+
+var _ Sink = (*DigestSink)(nil)
+
+// NewDigestSink creates a sink that buffers listings, sending them to another sink every interval.
+// Run must be called for buffered listings to be sent.
+func NewDigestSink(sink Sink, interval time.Duration) *DigestSink {
+	return &DigestSink{
+		sink:     sink,
+		interval: interval,
+	}
+}
+
+// Send buffers listings until the next digest is sent.
+func (s *DigestSink) Send(_ context.Context, listings ...twigots.TicketListing) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.listings = append(s.listings, listings...)
+	return nil
+}
+
+// Flush sends all buffered listings now. Does nothing if there are no buffered listings.
+// If sending fails, the listings are kept buffered so they are sent in the next digest.
+func (s *DigestSink) Flush(ctx context.Context) error {
+	s.mutex.Lock()
+	listings := s.listings
+	s.listings = nil
+	s.mutex.Unlock()
+
+	if len(listings) == 0 {
+		return nil
+	}
+
+	err := s.sink.Send(ctx, listings...)
+	if err != nil {
+		s.mutex.Lock()
+		s.listings = append(listings, s.listings...)
+		s.mutex.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Run sends buffered listings every interval until the context is cancelled.
+// Any errors sending are passed to onError, which can be nil.
+//
+// Returns the context error once the context is cancelled.
+func (s *DigestSink) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			err := s.Flush(ctx)
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// Default port of SMTP servers using STARTTLS
+const DefaultSMTPPort = 587
+
+// EmailConfig is the configuration of an email sink.
+type EmailConfig struct {
+	// Host of the SMTP server. Required.
+	Host string `json:"host"`
+
+	// Port of the SMTP server. Defaults to DefaultSMTPPort.
+	Port int `json:"port,omitempty"`
+
+	// Username and password used to authenticate with the SMTP server using PLAIN auth.
+	// Leave username empty to not authenticate.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"` // nolint: gosec
+
+	// From is the address emails are sent from. Required.
+	From string `json:"from"`
+
+	// To are the addresses emails are sent to. Required.
+	To []string `json:"to"`
+
+	// DisableStartTLS disables upgrading the connection to TLS using STARTTLS.
+	// By default, STARTTLS is required. Only disable this for trusted local servers.
+	DisableStartTLS bool `json:"disableStartTLS,omitempty"`
+
+	// Digest sends a single email containing all listings, grouped by event, rather than an email per listing.
+	// Use with a DigestSink to send a periodic digest.
+	Digest bool `json:"digest,omitempty"`
+
+	// TLSConfig used for STARTTLS. If nil, a default config using the host as the server name is used.
+	TLSConfig *tls.Config `json:"-"`
+}
+
+func (c EmailConfig) validate() error {
+	if c.Host == "" {
+		return errors.New("host must be set")
+	}
+	if c.From == "" {
+		return errors.New("from address must be set")
+	}
+	if len(c.To) == 0 {
+		return errors.New("to addresses must be set")
+	}
+	return nil
+}
+
+// EmailSink is a sink that sends emails using SMTP.
+//
+// Emails are multipart messages, with both plain text and HTML parts, containing the price,
+// discount, seating, venue, event date and link of each listing.
+type EmailSink struct {
+	config EmailConfig
+}
+
+var _ Sink = (*EmailSink)(nil)
+
+// NewEmailSink creates a sink that sends emails using the specified config.
+func NewEmailSink(config EmailConfig) (*EmailSink, error) {
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid email config: %w", err)
+	}
+	if config.Port == 0 {
+		config.Port = DefaultSMTPPort
+	}
+	return &EmailSink{config: config}, nil
+}
+
+func (s *EmailSink) Send(ctx context.Context, listings ...twigots.TicketListing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	if s.config.Digest {
+		return s.sendEmail(ctx, newEmailData(listings))
+	}
+
+	for idx, listing := range listings {
+		err := s.sendEmail(ctx, newEmailData([]twigots.TicketListing{listing}))
+		if err == nil {
+			continue
+		}
+		if idx == 0 {
+			return err
+		}
+
+		// Report which listings were not emailed, so they can be retried without emailing the others again
+		return &PartialSendError{Unsent: listings[idx:], Err: err}
+	}
+	return nil
+}
+
+func (s *EmailSink) sendEmail(ctx context.Context, data emailData) error {
+	message, err := buildEmail(s.config.From, s.config.To, data)
+	if err != nil {
+		return err
+	}
+
+	return s.sendSMTP(ctx, message)
+}
+
+// sendSMTP sends a message using SMTP.
+func (s *EmailSink) sendSMTP(ctx context.Context, message []byte) error {
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return fmt.Errorf("failed to create smtp client: %w", err)
+	}
+	defer client.Close()
+
+	if !s.config.DisableStartTLS {
+		tlsConfig := s.config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}
+		}
+
+		err := client.StartTLS(tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		err := client.Auth(auth)
+		if err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	err = client.Mail(s.config.From)
+	if err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, to := range s.config.To {
+		err := client.Rcpt(to)
+		if err != nil {
+			return fmt.Errorf("failed to set recipient: %w", err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start data: %w", err)
+	}
+	_, err = writer.Write(message)
+	if err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// emailData is the data used to render emails.
+type emailData struct {
+	Subject string
+	Events  []emailEvent
+}
+
+// emailEvent is an event, and the listings for it, in an email.
+type emailEvent struct {
+	Name     string
+	Date     string
+	Venue    string
+	Listings []emailListing
+}
+
+// emailListing is a listing in an email.
+type emailListing struct {
+	NumTickets         int
+	TicketPriceInclFee string
+	TotalPriceInclFee  string
+	OriginalPrice      string
+	Discount           string
+	Seating            string
+	URL                string
+}
+
+func newEmailData(listings []twigots.TicketListing) emailData {
+	groups := twigots.TicketListings(listings).GroupByEvent()
+	events := make([]emailEvent, 0, len(groups))
+	for _, event := range twigots.TicketListings(listings).Events() {
+		eventListings := groups[event.Id]
+
+		emailListings := make([]emailListing, 0, len(eventListings))
+		for _, listing := range eventListings {
+			emailListings = append(emailListings, emailListing{
+				NumTickets:         listing.NumTickets,
+				TicketPriceInclFee: listing.TicketPriceInclFee().String(),
+				TotalPriceInclFee:  listing.TotalPriceInclFee().String(),
+				OriginalPrice:      listing.OriginalTicketPrice().String(),
+				Discount:           listing.DiscountString(),
+				Seating:            seating(listing),
+				URL:                twigots.ListingURL(listing.Id, listing.NumTickets),
+			})
+		}
+
+		events = append(events, emailEvent{
+			Name:     strings.TrimSpace(event.Name),
+			Date:     eventDate(event),
+			Venue:    event.Venue.Name,
+			Listings: emailListings,
+		})
+	}
+
+	subject := fmt.Sprintf("%d new ticket listings", len(listings))
+	if len(listings) == 1 {
+		subject = fmt.Sprintf("New ticket listing: %s", events[0].Name)
+	}
+
+	return emailData{
+		Subject: subject,
+		Events:  events,
+	}
+}
+
+// seating describes the seating of a listing e.g. "Seated - Section A, Row 12".
+func seating(listing twigots.TicketListing) string {
+	parts := make([]string, 0, 2)
+	if listing.Section != "" {
+		parts = append(parts, "Section "+listing.Section)
+	}
+	if listing.Row != "" {
+		parts = append(parts, "Row "+listing.Row)
+	}
+
+	ticketType := strings.TrimSpace(listing.TicketType)
+	if len(parts) == 0 {
+		return ticketType
+	}
+	if ticketType == "" {
+		return strings.Join(parts, ", ")
+	}
+	return ticketType + " - " + strings.Join(parts, ", ")
+}
+
+func eventDate(event twigots.Event) string {
+	if event.Date.IsZero() {
+		return ""
+	}
+	return event.StartTime().Format("Mon 2 Jan 2006 15:04")
+}
+
+var emailTextTemplate = template.Must(template.New("text").Parse(
+	`{{ range .Events }}{{ .Name }}
+{{ if .Date }}{{ .Date }}{{ end }}{{ if .Venue }} at {{ .Venue }}{{ end }}
+{{ range .Listings }}
+- {{ .NumTickets }} ticket(s) at {{ .TicketPriceInclFee }} each ({{ .TotalPriceInclFee }} total)
+  Original price: {{ .OriginalPrice }} each. Discount: {{ .Discount }}
+{{- if .Seating }}
+  Seating: {{ .Seating }}{{ end }}
+  {{ .URL }}
+{{ end }}
+{{ end }}`,
+))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(
+	`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+{{ range .Events }}
+<h2 style="margin-bottom: 0;">{{ .Name }}</h2>
+<p style="margin-top: 4px; color: #555;">{{ .Date }}{{ if .Venue }} at {{ .Venue }}{{ end }}</p>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="text-align: left;">
+<th>Tickets</th><th>Price</th><th>Total</th><th>Original Price</th><th>Discount</th><th>Seating</th><th></th>
+</tr>
+{{ range .Listings }}
+<tr>
+<td>{{ .NumTickets }}</td>
+<td>{{ .TicketPriceInclFee }}</td>
+<td>{{ .TotalPriceInclFee }}</td>
+<td>{{ .OriginalPrice }}</td>
+<td>{{ .Discount }}</td>
+<td>{{ .Seating }}</td>
+<td><a href="{{ .URL }}">View listing</a></td>
+</tr>
+{{ end }}
+</table>
+{{ end }}
+</body>
+</html>
+`,
+))
+
+// buildEmail builds a multipart/alternative email message with plain text and html parts.
+func buildEmail(from string, to []string, data emailData) ([]byte, error) {
+	var textBody, htmlBody bytes.Buffer
+	err := emailTextTemplate.Execute(&textBody, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render email text: %w", err)
+	}
+	err = emailHTMLTemplate.Execute(&htmlBody, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render email html: %w", err)
+	}
+
+	var message bytes.Buffer
+	multipartWriter := multipart.NewWriter(&message)
+
+	headers := []struct{ key, value string }{
+		{"From", from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", data.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageId(from)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + multipartWriter.Boundary()},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header.key, header.value)
+	}
+	message.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", textBody.Bytes()},
+		{"text/html; charset=utf-8", htmlBody.Bytes()},
+	} {
+		partWriter, err := multipartWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create email part: %w", err)
+		}
+
+		err = writeQuotedPrintable(partWriter, part.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write email part: %w", err)
+		}
+	}
+
+	err = multipartWriter.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write email: %w", err)
+	}
+
+	return message.Bytes(), nil
+}
+
+func writeQuotedPrintable(writer io.Writer, body []byte) error {
+	quotedPrintableWriter := quotedprintable.NewWriter(writer)
+	_, err := quotedPrintableWriter.Write(body)
+	if err != nil {
+		return err
+	}
+	return quotedPrintableWriter.Close()
+}
+
+// messageId generates a unique message id using the domain of the from address.
+func messageId(from string) string {
+	domain := "twigots"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		domain = strings.Trim(from[at+1:], "<> ")
+	}
+
+	randomBytes := make([]byte, 16)
+	_, _ = rand.Read(randomBytes)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(randomBytes), domain)
+}
@@ -0,0 +1,307 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is a minimal in-process smtp server that records received messages.
+type smtpServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+
+	// rejectContaining causes messages containing it to be rejected, if set.
+	rejectContaining string
+
+	mutex    sync.Mutex
+	messages []string
+	auths    []string
+}
+
+func newSMTPServer(t *testing.T) (*smtpServer, *tls.Config) {
+	// Borrow the test certificate of a tls http server
+	httpServer := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(httpServer.Close)
+	clientTLSConfig := httpServer.Client().Transport.(*http.Transport).TLSClientConfig.Clone() // nolint: forcetypeassert
+	clientTLSConfig.ServerName = "127.0.0.1"
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &smtpServer{
+		listener:  listener,
+		tlsConfig: httpServer.TLS,
+	}
+	go server.serve()
+
+	return server, clientTLSConfig
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port // nolint: forcetypeassert
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ready")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			_ = text.PrintfLine("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+		case "STARTTLS":
+			_ = text.PrintfLine("220 ready to start tls")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			err := tlsConn.Handshake()
+			if err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(tlsConn)
+		case "AUTH":
+			_, credentials, _ := strings.Cut(argument, " ")
+			decodedCredentials, _ := base64.StdEncoding.DecodeString(credentials)
+			s.mutex.Lock()
+			s.auths = append(s.auths, string(decodedCredentials))
+			s.mutex.Unlock()
+			_ = text.PrintfLine("235 authenticated")
+		case "MAIL", "RCPT":
+			_ = text.PrintfLine("250 ok")
+		case "DATA":
+			_ = text.PrintfLine("354 send data")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			if s.rejectContaining != "" && strings.Contains(string(data), s.rejectContaining) {
+				_ = text.PrintfLine("554 message rejected")
+				continue
+			}
+			s.mutex.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mutex.Unlock()
+			_ = text.PrintfLine("250 ok")
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *smtpServer) receivedMessages() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+func TestEmailSink(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t)
+
+	sink, err := NewEmailSink(EmailConfig{
+		Host:      "127.0.0.1",
+		Port:      server.port(),
+		Username:  "user",
+		Password:  "password",
+		From:      "twigots@example.com",
+		To:        []string{"me@example.com"},
+		TLSConfig: tlsConfig,
+	})
+	require.NoError(t, err)
+
+	listing := emailTestListing("1", "event1", "Coldplay", 2, 100)
+	listing2 := emailTestListing("2", "event2", "Hamilton", 1, 50)
+	err = sink.Send(context.Background(), listing, listing2)
+	require.NoError(t, err)
+
+	messages := server.receivedMessages()
+	require.Len(t, messages, 2)
+	server.mutex.Lock()
+	require.Equal(t, []string{"\x00user\x00password", "\x00user\x00password"}, server.auths)
+	server.mutex.Unlock()
+
+	subject, textBody, htmlBody := parseEmail(t, messages[0])
+	require.Equal(t, "New ticket listing: Coldplay", subject)
+	require.Contains(t, textBody, "2 ticket(s) at £55.00 each (£110.00 total)")
+	require.Contains(t, textBody, "Seated - Section A, Row 12")
+	require.Contains(t, textBody, "https://www.twickets.live/app/block/1,2")
+	require.Contains(t, htmlBody, `<a href="https://www.twickets.live/app/block/1,2">`)
+	require.Contains(t, htmlBody, "Wembley Stadium")
+}
+
+func TestEmailSinkPartialSend(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t)
+	server.rejectContaining = "Subject: New ticket listing: Hamilton"
+
+	sink, err := NewEmailSink(EmailConfig{
+		Host:      "127.0.0.1",
+		Port:      server.port(),
+		From:      "twigots@example.com",
+		To:        []string{"me@example.com"},
+		TLSConfig: tlsConfig,
+	})
+	require.NoError(t, err)
+
+	err = sink.Send(
+		context.Background(),
+		emailTestListing("1", "event1", "Coldplay", 2, 100),
+		emailTestListing("2", "event2", "Hamilton", 1, 50),
+		emailTestListing("3", "event3", "Oasis", 4, 180),
+	)
+
+	// Only the listings from the failed email onwards should be reported as unsent
+	var partialErr *PartialSendError
+	require.ErrorAs(t, err, &partialErr)
+	require.Len(t, partialErr.Unsent, 2)
+	require.Equal(t, "2", partialErr.Unsent[0].Id)
+	require.Equal(t, "3", partialErr.Unsent[1].Id)
+	require.Len(t, server.receivedMessages(), 1)
+}
+
+func TestEmailSinkDigest(t *testing.T) {
+	server, tlsConfig := newSMTPServer(t)
+
+	sink, err := NewEmailSink(EmailConfig{
+		Host:      "127.0.0.1",
+		Port:      server.port(),
+		From:      "twigots@example.com",
+		To:        []string{"me@example.com"},
+		Digest:    true,
+		TLSConfig: tlsConfig,
+	})
+	require.NoError(t, err)
+
+	err = sink.Send(
+		context.Background(),
+		emailTestListing("1", "event1", "Coldplay", 2, 100),
+		emailTestListing("2", "event2", "Hamilton", 1, 50),
+		emailTestListing("3", "event1", "Coldplay", 4, 180),
+	)
+	require.NoError(t, err)
+
+	messages := server.receivedMessages()
+	require.Len(t, messages, 1)
+
+	subject, textBody, htmlBody := parseEmail(t, messages[0])
+	require.Equal(t, "3 new ticket listings", subject)
+
+	// Listings should be grouped by event
+	coldplayIdx := strings.Index(textBody, "Coldplay")
+	hamiltonIdx := strings.Index(textBody, "Hamilton")
+	require.NotEqual(t, -1, coldplayIdx)
+	require.Less(t, coldplayIdx, hamiltonIdx)
+	require.Less(t, strings.Index(textBody, "block/3,4"), hamiltonIdx)
+	require.Equal(t, 2, strings.Count(htmlBody, "<h2"))
+}
+
+func TestEmailSinkInvalidConfig(t *testing.T) {
+	_, err := NewEmailSink(EmailConfig{Host: "127.0.0.1", From: "twigots@example.com"})
+	require.Error(t, err)
+}
+
+func TestDigestSink(t *testing.T) {
+	var sent [][]twigots.TicketListing
+	sink := NewDigestSink(SinkFunc(func(_ context.Context, listings ...twigots.TicketListing) error {
+		sent = append(sent, listings)
+		return nil
+	}), time.Hour)
+
+	err := sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	err = sink.Send(context.Background(), testListing("2", "Hamilton", 1, 50))
+	require.NoError(t, err)
+	require.Empty(t, sent)
+
+	err = sink.Flush(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	require.Len(t, sent[0], 2)
+
+	// Nothing buffered, so nothing should be sent
+	err = sink.Flush(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+}
+
+// parseEmail parses an email, returning its subject and decoded plain text and html bodies.
+func parseEmail(t *testing.T, message string) (subject, textBody, htmlBody string) {
+	parsedMessage, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(message)))
+	require.NoError(t, err)
+
+	subject, err = new(mime.WordDecoder).DecodeHeader(parsedMessage.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(parsedMessage.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsedMessage.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		// multipart reader transparently decodes quoted printable parts
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			textBody = string(body)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			htmlBody = string(body)
+		}
+	}
+
+	require.NotEmpty(t, textBody)
+	require.NotEmpty(t, htmlBody)
+	return subject, textBody, htmlBody
+}
+
+func emailTestListing(id, eventId, eventName string, numTickets, totalPrice int) twigots.TicketListing {
+	listing := testListing(id, eventName, numTickets, totalPrice)
+	listing.Event.Id = eventId
+	listing.Event.Venue.Name = "Wembley Stadium"
+	listing.TicketType = "Seated"
+	listing.Section = "A"
+	listing.Row = "12"
+	return listing
+}
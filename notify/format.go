@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+const (
+	// DefaultTitleTemplate is the default template used for message titles.
+	DefaultTitleTemplate = `{{ if eq (len .Listings) 1 }}{{ .Listing.Event.Name }}` +
+		`{{ else }}{{ len .Listings }} new ticket listings{{ end }}`
+
+	// DefaultBodyTemplate is the default template used for message bodies.
+	DefaultBodyTemplate = `{{ range $i, $listing := .Listings }}{{ if $i }}{{ "\n" }}{{ end }}` +
+		`{{ summary $listing }}{{ end }}`
+)
+
+// templateFuncs are functions available in message templates.
+var templateFuncs = template.FuncMap{
+	"summary": Summary,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+}
+
+// Message is a notification message.
+type Message struct {
+	Title string
+	Body  string
+
+	// URL to open when the notification is clicked.
+	// Only set when the message is about a single listing.
+	URL string
+
+	// Listings the message is about.
+	Listings []twigots.TicketListing
+}
+
+// TemplateData is the data available in message templates.
+type TemplateData struct {
+	// Listings the message is about.
+	Listings []twigots.TicketListing
+
+	// Listing is the first listing the message is about.
+	// Use this in templates for messages about a single listing.
+	Listing twigots.TicketListing
+}
+
+// Formatter formats ticket listings as notification messages using Go templates.
+// See text/template for the template syntax, and TemplateData for the data available.
+//
+// As well as the builtin template functions, the following functions are available:
+//   - summary: Gets a single line summary of a listing. See Summary.
+//   - upper: Converts a string to upper case.
+//   - lower: Converts a string to lower case.
+type Formatter struct {
+	title *template.Template
+	body  *template.Template
+
+	batchSize int
+}
+
+// NewFormatter creates a formatter using the specified title and body templates.
+// Empty templates will use DefaultTitleTemplate and DefaultBodyTemplate respectively.
+//
+// batchSize is the maximum number of listings included in a single message.
+// Set to <=1 to send a message per listing.
+func NewFormatter(titleTemplate, bodyTemplate string, batchSize int) (*Formatter, error) {
+	if titleTemplate == "" {
+		titleTemplate = DefaultTitleTemplate
+	}
+	if bodyTemplate == "" {
+		bodyTemplate = DefaultBodyTemplate
+	}
+
+	title, err := template.New("title").Funcs(templateFuncs).Parse(titleTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title template: %w", err)
+	}
+
+	body, err := template.New("body").Funcs(templateFuncs).Parse(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	return &Formatter{
+		title:     title,
+		body:      body,
+		batchSize: batchSize,
+	}, nil
+}
+
+// DefaultFormatter creates a formatter using the default templates, sending a message per listing.
+func DefaultFormatter() *Formatter {
+	formatter, err := NewFormatter("", "", 1)
+	if err != nil {
+		// The default templates are always valid.
+		// If an error does occur (due to an error in the code), panic so we catch it.
+		panic(err)
+	}
+	return formatter
+}
+
+// Format ticket listings as messages, batching listings as configured.
+func (f *Formatter) Format(listings ...twigots.TicketListing) ([]Message, error) {
+	messages := make([]Message, 0, len(listings)/f.batchSize+1)
+	for start := 0; start < len(listings); start += f.batchSize {
+		end := min(start+f.batchSize, len(listings))
+		message, err := f.formatMessage(listings[start:end])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (f *Formatter) formatMessage(listings []twigots.TicketListing) (Message, error) {
+	data := TemplateData{
+		Listings: listings,
+		Listing:  listings[0],
+	}
+
+	var title bytes.Buffer
+	err := f.title.Execute(&title, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to execute title template: %w", err)
+	}
+
+	var body bytes.Buffer
+	err = f.body.Execute(&body, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to execute body template: %w", err)
+	}
+
+	var url string
+	if len(listings) == 1 {
+		url = listings[0].URL()
+	}
+
+	return Message{
+		Title:    strings.TrimSpace(title.String()),
+		Body:     strings.TrimSpace(body.String()),
+		URL:      url,
+		Listings: listings,
+	}, nil
+}
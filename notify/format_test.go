@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultFormatter(t *testing.T) {
+	messages, err := DefaultFormatter().Format(
+		testListing("1", "Coldplay", 2, 100),
+		testListing("2", "Hamilton", 1, 50),
+	)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	require.Equal(t, "Coldplay", messages[0].Title)
+	require.Equal(t, "Coldplay - 2 tickets at £55.00 each https://www.twickets.live/app/block/1,2", messages[0].Body)
+	require.Equal(t, "https://www.twickets.live/app/block/1,2", messages[0].URL)
+	require.Len(t, messages[0].Listings, 1)
+
+	require.Equal(t, "Hamilton", messages[1].Title)
+}
+
+func TestFormatterBatching(t *testing.T) {
+	formatter, err := NewFormatter("", "", 2)
+	require.NoError(t, err)
+
+	messages, err := formatter.Format(
+		testListing("1", "Coldplay", 2, 100),
+		testListing("2", "Hamilton", 1, 50),
+		testListing("3", "Oasis", 4, 400),
+	)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	require.Equal(t, "2 new ticket listings", messages[0].Title)
+	require.Equal(
+		t,
+		"Coldplay - 2 tickets at £55.00 each https://www.twickets.live/app/block/1,2\n"+
+			"Hamilton - 1 ticket at £55.00 each https://www.twickets.live/app/block/2,1",
+		messages[0].Body,
+	)
+	require.Empty(t, messages[0].URL)
+
+	require.Equal(t, "Oasis", messages[1].Title)
+	require.Equal(t, "https://www.twickets.live/app/block/3,4", messages[1].URL)
+}
+
+func TestFormatterCustomTemplates(t *testing.T) {
+	formatter, err := NewFormatter(
+		`{{ upper .Listing.Event.Name }}`,
+		`{{ .Listing.NumTickets }} for {{ .Listing.TotalPriceInclFee }}`,
+		1,
+	)
+	require.NoError(t, err)
+
+	messages, err := formatter.Format(testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, "COLDPLAY", messages[0].Title)
+	require.Equal(t, "2 for £110.00", messages[0].Body)
+
+	_, err = NewFormatter(`{{ .Listing`, "", 1)
+	require.ErrorContains(t, err, "invalid title template")
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// Sink is a destination that ticket listing notifications can be sent to.
+type Sink interface {
+	// Send notifications of the ticket listings to the sink.
+	// Sending no listings is a no-op.
+	Send(ctx context.Context, listings ...twigots.TicketListing) error
+}
+
+// SinkFunc is a function that implements Sink.
+type SinkFunc func(ctx context.Context, listings ...twigots.TicketListing) error
+
+func (f SinkFunc) Send(ctx context.Context, listings ...twigots.TicketListing) error {
+	return f(ctx, listings...)
+}
+
+// MultiSink creates a sink that sends notifications to all of the provided sinks.
+//
+// Notifications are sent to every sink, even if sending to an earlier sink fails.
+// Any errors are joined and returned.
+func MultiSink(sinks ...Sink) Sink {
+	return SinkFunc(func(ctx context.Context, listings ...twigots.TicketListing) error {
+		var errs []error
+		for _, sink := range sinks {
+			err := sink.Send(ctx, listings...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// Summary is a short, single line, human readable summary of a ticket listing.
+//
+// Format is:
+// <event name> - <num tickets> ticket(s) at <ticket price incl fee> each [(<discount> off)] <url>
+func Summary(listing twigots.TicketListing) string {
+	ticketWord := "tickets"
+	if listing.NumTickets == 1 {
+		ticketWord = "ticket"
+	}
+
+	var discount string
+	if listing.Discount() > 0 {
+		discount = fmt.Sprintf(" (%s off)", listing.DiscountString())
+	}
+
+	return fmt.Sprintf(
+		"%s - %d %s at %s each%s %s",
+		strings.TrimSpace(listing.Event.Name),
+		listing.NumTickets, ticketWord,
+		listing.TicketPriceInclFee().String(),
+		discount,
+		listing.URL(),
+	)
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// Default initial delay between retries
+const DefaultRetryDelay = time.Second
+
+// RetrySink creates a sink that retries sending to another sink if sending fails.
+//
+// Sending is attempted at most maxAttempts times in total, with the delay between attempts
+// doubling after each attempt. If delay is <= 0, DefaultRetryDelay is used.
+//
+// Errors that will never succeed if retried, such as a response with a 4xx status code
+// (except 429 Too Many Requests), are not retried. If the sink returns a *PartialSendError,
+// only the listings that were not sent are retried.
+func RetrySink(sink Sink, maxAttempts int, delay time.Duration) Sink {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+
+	return SinkFunc(func(ctx context.Context, listings ...twigots.TicketListing) error {
+		var err error
+		var attempt int
+		attemptDelay := delay
+		for attempt = 1; attempt <= maxAttempts; attempt++ {
+			err = sink.Send(ctx, listings...)
+			if err == nil || !retryable(err) || attempt == maxAttempts {
+				break
+			}
+
+			var partialErr *PartialSendError
+			if errors.As(err, &partialErr) {
+				listings = partialErr.Unsent
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(attemptDelay):
+			}
+			attemptDelay *= 2
+		}
+
+		if err != nil && attempt > 1 {
+			return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+		}
+		return err
+	})
+}
+
+// retryable returns whether sending should be retried after an error.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	return true
+}
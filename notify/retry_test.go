@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrySink(t *testing.T) {
+	// Should succeed after failing twice
+	attempts := 0
+	sink := RetrySink(SinkFunc(func(context.Context, ...twigots.TicketListing) error {
+		attempts++
+		if attempts < 3 {
+			return &StatusError{StatusCode: http.StatusServiceUnavailable}
+		}
+		return nil
+	}), 3, time.Millisecond)
+
+	err := sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	// Should fail after max attempts
+	attempts = 0
+	sink = RetrySink(SinkFunc(func(context.Context, ...twigots.TicketListing) error {
+		attempts++
+		return errors.New("failed")
+	}), 3, time.Millisecond)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.ErrorContains(t, err, "failed after 3 attempts")
+	require.Equal(t, 3, attempts)
+
+	// Should not retry client errors
+	attempts = 0
+	sink = RetrySink(SinkFunc(func(context.Context, ...twigots.TicketListing) error {
+		attempts++
+		return &StatusError{StatusCode: http.StatusBadRequest}
+	}), 3, time.Millisecond)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.Error(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestRetrySinkPartialSend(t *testing.T) {
+	var sentContents []string
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		require.NoError(t, err)
+
+		// Fail the second message once
+		if strings.Contains(payload["content"], "Hamilton") && !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		sentContents = append(sentContents, payload["content"])
+	}))
+	t.Cleanup(server.Close)
+
+	formatter, err := NewFormatter("{{ .Listing.Event.Name }}", "", 1)
+	require.NoError(t, err)
+	discordSink, err := NewDiscordSink(server.URL, formatter, server.Client())
+	require.NoError(t, err)
+
+	// Messages that were sent before the failure should not be sent again
+	sink := RetrySink(discordSink, 3, time.Millisecond)
+	err = sink.Send(
+		context.Background(),
+		testListing("1", "Coldplay", 2, 100),
+		testListing("2", "Hamilton", 1, 50),
+		testListing("3", "Oasis", 1, 50),
+	)
+	require.NoError(t, err)
+	require.Len(t, sentContents, 3)
+	require.Contains(t, sentContents[0], "Coldplay")
+	require.Contains(t, sentContents[1], "Hamilton")
+	require.Contains(t, sentContents[2], "Oasis")
+}
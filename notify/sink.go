@@ -0,0 +1,257 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// DefaultTimeout is the timeout of requests made by sinks that are not given a http client.
+const DefaultTimeout = 30 * time.Second
+
+// defaultClient is the http client used by sinks that are not given one,
+// so a hung endpoint cannot block sending forever.
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// WriterSink is a sink that writes notifications to a writer.
+type WriterSink struct {
+	writer io.Writer
+	json   bool
+	mutex  sync.Mutex
+}
+
+var _ Sink = (*WriterSink)(nil)
+
+// NewTextSink creates a sink that writes a human readable summary of each listing to a writer, one per line.
+// See Summary for the format.
+func NewTextSink(writer io.Writer) *WriterSink {
+	return &WriterSink{writer: writer}
+}
+
+// NewJSONSink creates a sink that writes each listing to a writer as newline delimited JSON.
+func NewJSONSink(writer io.Writer) *WriterSink {
+	return &WriterSink{writer: writer, json: true}
+}
+
+func (s *WriterSink) Send(_ context.Context, listings ...twigots.TicketListing) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	encoder := json.NewEncoder(s.writer)
+	for _, listing := range listings {
+		var err error
+		if s.json {
+			err = encoder.Encode(listing)
+		} else {
+			_, err = fmt.Fprintln(s.writer, Summary(listing))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// FileSink is a sink that appends each listing to a file as newline delimited JSON.
+// The file is created if it does not exist.
+type FileSink struct {
+	path  string
+	mutex sync.Mutex
+}
+
+var _ Sink = (*FileSink)(nil)
+
+// NewFileSink creates a sink that appends each listing to the file at the specified path.
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Send(ctx context.Context, listings ...twigots.TicketListing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+
+	err = NewJSONSink(file).Send(ctx, listings...)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// ExecSink is a sink that runs a command for each listing,
+// with the listing JSON written to the command's standard input.
+type ExecSink struct {
+	command []string
+}
+
+var _ Sink = (*ExecSink)(nil)
+
+// NewExecSink creates a sink that runs the specified command (and arguments) for each listing.
+func NewExecSink(command ...string) (*ExecSink, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, errors.New("command must be set")
+	}
+	return &ExecSink{command: command}, nil
+}
+
+// Send runs the command for each listing. If the command fails for only some listings,
+// a *PartialSendError containing the failed listings is returned.
+func (s *ExecSink) Send(ctx context.Context, listings ...twigots.TicketListing) error {
+	var failed []twigots.TicketListing
+	var errs []error
+	for _, listing := range listings {
+		err := s.run(ctx, listing)
+		if err != nil {
+			failed = append(failed, listing)
+			errs = append(errs, err)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	if len(failed) == len(listings) {
+		return errors.Join(errs...)
+	}
+	return &PartialSendError{Unsent: failed, Err: errors.Join(errs...)}
+}
+
+func (s *ExecSink) run(ctx context.Context, listing twigots.TicketListing) error {
+	listingJson, err := json.Marshal(listing)
+	if err != nil {
+		return fmt.Errorf("failed to marshal listing: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...) // nolint: gosec
+	cmd.Stdin = bytes.NewReader(listingJson)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run command '%s': %w\n\nOutput:\n%s", s.command[0], err, output)
+	}
+	return nil
+}
+
+// WebhookSink is a sink that posts listings as JSON to a webhook url.
+//
+// The body of each request is a JSON object with a single "listings" field
+// containing an array of the listings.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+var _ Sink = (*WebhookSink)(nil)
+
+// NewWebhookSink creates a sink that posts listings to the specified url, with any additional headers.
+// If client is nil, a client with a timeout of DefaultTimeout is used.
+func NewWebhookSink(url string, headers map[string]string, client *http.Client) (*WebhookSink, error) {
+	if url == "" {
+		return nil, errors.New("url must be set")
+	}
+	if client == nil {
+		client = defaultClient
+	}
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  client,
+	}, nil
+}
+
+func (s *WebhookSink) Send(ctx context.Context, listings ...twigots.TicketListing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	body := struct {
+		Listings []twigots.TicketListing `json:"listings"`
+	}{Listings: listings}
+	return postJSON(ctx, s.client, s.url, s.headers, body)
+}
+
+// postJSON posts a JSON body to a url, returning an error if the response is not successful.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body any) error {
+	bodyJson, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(bodyJson))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	return doRequest(client, request)
+}
+
+// doRequest does a request, returning an error if the response is not successful.
+func doRequest(client *http.Client, request *http.Request) error {
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return &StatusError{
+			StatusCode: response.StatusCode,
+			Body:       string(responseBody),
+		}
+	}
+
+	return nil
+}
+
+// StatusError is returned when a notification request gets an unsuccessful response.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to send notification: %d %s\n\nResponse:\n%s",
+		e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// PartialSendError is returned by sinks that send several messages when some messages were sent
+// before sending failed. RetrySink uses it to only retry sending the listings that were not sent.
+type PartialSendError struct {
+	// Unsent are the listings that were not sent.
+	Unsent []twigots.TicketListing
+
+	Err error
+}
+
+func (e *PartialSendError) Error() string {
+	return fmt.Sprintf("failed to send %d listings: %s", len(e.Unsent), e.Err)
+}
+
+func (e *PartialSendError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextSink(t *testing.T) {
+	var buffer bytes.Buffer
+	err := NewTextSink(&buffer).Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		"Coldplay - 2 tickets at £55.00 each https://www.twickets.live/app/block/1,2\n",
+		buffer.String(),
+	)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listings.jsonl")
+	sink := NewFileSink(path)
+
+	err := sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+	err = sink.Send(context.Background(), testListing("2", "Hamilton", 1, 50))
+	require.NoError(t, err)
+
+	fileContents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(fileContents)), "\n")
+	require.Len(t, lines, 2)
+
+	var listing twigots.TicketListing
+	err = json.Unmarshal([]byte(lines[1]), &listing)
+	require.NoError(t, err)
+	require.Equal(t, "2", listing.Id)
+}
+
+func TestExecSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listing.json")
+	sink, err := NewExecSink("sh", "-c", `cat > "$0"`, path)
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.NoError(t, err)
+
+	fileContents, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var listing twigots.TicketListing
+	err = json.Unmarshal(fileContents, &listing)
+	require.NoError(t, err)
+	require.Equal(t, "1", listing.Id)
+
+	// Failing command should return an error
+	sink, err = NewExecSink("sh", "-c", "exit 1")
+	require.NoError(t, err)
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	require.Error(t, err)
+	var partialErr *PartialSendError
+	require.NotErrorAs(t, err, &partialErr)
+
+	// Command failing for only some listings should return the failed listings
+	sink, err = NewExecSink("sh", "-c", "! grep -q Hamilton")
+	require.NoError(t, err)
+	err = sink.Send(
+		context.Background(),
+		testListing("1", "Coldplay", 2, 100),
+		testListing("2", "Hamilton", 1, 50),
+		testListing("3", "Oasis", 4, 200),
+	)
+	require.ErrorAs(t, err, &partialErr)
+	require.Len(t, partialErr.Unsent, 1)
+	require.Equal(t, "2", partialErr.Unsent[0].Id)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var requestBody struct {
+		Listings []twigots.TicketListing `json:"listings"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+		err := json.NewDecoder(r.Body).Decode(&requestBody)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(server.URL, map[string]string{"X-Token": "secret"}, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(
+		context.Background(),
+		testListing("1", "Coldplay", 2, 100),
+		testListing("2", "Hamilton", 1, 50),
+	)
+	require.NoError(t, err)
+	require.Len(t, requestBody.Listings, 2)
+	require.Equal(t, "Hamilton", requestBody.Listings[1].Event.Name)
+}
+
+func TestWebhookSinkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(server.URL, nil, server.Client())
+	require.NoError(t, err)
+
+	err = sink.Send(context.Background(), testListing("1", "Coldplay", 2, 100))
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
+
+func TestWebhookSinkDefaultClient(t *testing.T) {
+	sink, err := NewWebhookSink("http://localhost", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultTimeout, sink.client.Timeout)
+}
+
+func TestSinkConfig(t *testing.T) {
+	_, err := Sinks(
+		SinkConfig{Type: SinkTypeStdout},
+		SinkConfig{Type: SinkTypeFile, Path: "listings.jsonl"},
+		SinkConfig{Type: SinkTypeExec, Command: []string{"cat"}},
+		SinkConfig{Type: SinkTypeWebhook, URL: "http://localhost"},
+	)
+	require.NoError(t, err)
+
+	_, err = Sinks(SinkConfig{Type: SinkTypeFile})
+	require.ErrorContains(t, err, "invalid sink 1")
+
+	_, err = Sinks(SinkConfig{Type: "pigeon"})
+	require.ErrorContains(t, err, "sink type 'pigeon' is not valid")
+}
+
+// testListing creates a test listing. Price is specified in pounds.
+// The twickets fee is 10% of the total price excluding fee.
+func testListing(id, eventName string, numTickets, totalPrice int) twigots.TicketListing {
+	return twigots.TicketListing{
+		Id:         id,
+		NumTickets: numTickets,
+		Event:      twigots.Event{Name: eventName},
+		TotalPriceExclFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 100,
+		},
+		TwicketsFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 10,
+		},
+		OriginalTotalPrice: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 100,
+		},
+	}
+}
@@ -1,27 +1,55 @@
 package twigots
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/orsinium-labs/enum"
 )
 
+// Errors returned by checked price arithmetic.
+var (
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrPriceOverflow    = errors.New("price overflows")
+)
+
 type Price struct {
 	Currency Currency `json:"currencyCode"`
 
-	// Amount is the cost in Cents, Pennies etc.
+	// Amount is the cost in the minor unit of the currency e.g. Cents, Pennies etc.
+	// The number of minor units in a major unit is given by the currency exponent.
+	// See Currency.Exponent.
 	// Prefer using `Number`
-	Amount int `json:"amountInCents"`
+	Amount int64 `json:"amountInCents"`
+}
+
+// UnmarshalJSON unmarshals a price, keeping an unknown currency code rather than failing.
+// See Currency.
+func (p *Price) UnmarshalJSON(data []byte) error {
+	type price Price
+	aux := struct {
+		*price
+		Currency string `json:"currencyCode"`
+	}{price: (*price)(p)}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	p.Currency = Currency{aux.Currency}
+	return nil
 }
 
 // Number is the numerical value of the price.
 // E.g. Dollars, Pounds, Euros etc.
 // Use this over `Amount“.
 func (p Price) Number() float64 {
-	return float64(p.Amount) / 100
+	return float64(p.Amount) / math.Pow10(p.Currency.Exponent())
 }
 
 // The price as a string.
@@ -32,6 +60,8 @@ func (p Price) String() string {
 
 // Add prices together. Currency will be kept.
 // Returns a new price.
+//
+// The currency of the other price is ignored. Use CheckedAdd to check currencies match.
 func (p Price) Add(other Price) Price {
 	return Price{
 		Currency: p.Currency,
@@ -41,6 +71,8 @@ func (p Price) Add(other Price) Price {
 
 // Subtract price from another. Currency will be kept.
 // Returns a new price.
+//
+// The currency of the other price is ignored. Use CheckedSubtract to check currencies match.
 func (p Price) Subtract(other Price) Price {
 	return Price{
 		Currency: p.Currency,
@@ -52,21 +84,225 @@ func (p Price) Subtract(other Price) Price {
 func (p Price) Multiply(num int) Price {
 	return Price{
 		Currency: p.Currency,
-		Amount:   p.Amount * num,
+		Amount:   p.Amount * int64(num),
 	}
 }
 
 // Divide prices. Currency will be kept.
-// Returns a new price.
+// Returns a new price, rounded to the nearest minor unit.
+//
+// As each division is rounded, multiplying the result by num may not give the original price.
+// Use Split to divide a price exactly.
+//
+// Dividing by zero returns a zero price, rather than panicking, as listings
+// without a number of tickets would otherwise panic when their prices are calculated.
 func (p Price) Divide(num int) Price {
+	if num == 0 {
+		return Price{Currency: p.Currency}
+	}
 	return Price{
 		Currency: p.Currency,
-		Amount:   int(math.Round(float64(p.Amount) / float64(num))),
+		Amount:   roundedDivide(p.Amount, int64(num)),
+	}
+}
+
+// CheckedAdd adds prices together, returning an error if their currencies
+// do not match or the result overflows.
+//
+// A price with an unset currency can be added to a price of any currency.
+func (p Price) CheckedAdd(other Price) (Price, error) {
+	currency, err := matchCurrencies(p, other)
+	if err != nil {
+		return Price{}, err
+	}
+
+	amount := p.Amount + other.Amount
+	if (other.Amount > 0 && amount < p.Amount) || (other.Amount < 0 && amount > p.Amount) {
+		return Price{}, fmt.Errorf("%w: %s + %s", ErrPriceOverflow, p, other)
+	}
+
+	return Price{Currency: currency, Amount: amount}, nil
+}
+
+// CheckedSubtract subtracts a price from another, returning an error if their
+// currencies do not match or the result overflows.
+//
+// A price with an unset currency can be subtracted from a price of any currency.
+func (p Price) CheckedSubtract(other Price) (Price, error) {
+	currency, err := matchCurrencies(p, other)
+	if err != nil {
+		return Price{}, err
+	}
+
+	amount := p.Amount - other.Amount
+	if (other.Amount > 0 && amount > p.Amount) || (other.Amount < 0 && amount < p.Amount) {
+		return Price{}, fmt.Errorf("%w: %s - %s", ErrPriceOverflow, p, other)
+	}
+
+	return Price{Currency: currency, Amount: amount}, nil
+}
+
+// CheckedMultiply multiplies a price, returning an error if the result overflows.
+func (p Price) CheckedMultiply(num int) (Price, error) {
+	multiplier := int64(num)
+	amount := p.Amount * multiplier
+	if p.Amount != 0 && (amount/p.Amount != multiplier ||
+		(p.Amount == -1 && multiplier == math.MinInt64) ||
+		(multiplier == -1 && p.Amount == math.MinInt64)) {
+		return Price{}, fmt.Errorf("%w: %s * %d", ErrPriceOverflow, p, num)
+	}
+
+	return Price{Currency: p.Currency, Amount: amount}, nil
+}
+
+// Split a price into num prices that sum exactly to the original price
+// e.g. splitting a total price between tickets. Prices differ by at most one minor unit,
+// with any remainder given to the first prices.
+//
+// Returns an error if num is not positive.
+func (p Price) Split(num int) ([]Price, error) {
+	if num <= 0 {
+		return nil, fmt.Errorf("cannot split price into %d parts", num)
+	}
+
+	ratios := make([]int, num)
+	for idx := range ratios {
+		ratios[idx] = 1
 	}
+	return p.Allocate(ratios...)
+}
+
+// Allocate a price between parts in proportion to their ratios, returning a price
+// for each ratio. Prices sum exactly to the original price, with any remainder
+// given one minor unit at a time to the first parts.
+//
+// Returns an error if no ratios are given, any ratio is negative or all ratios are zero.
+func (p Price) Allocate(ratios ...int) ([]Price, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("at least one ratio must be given")
+	}
+
+	var total int64
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("ratio %d is negative", ratio)
+		}
+		total += int64(ratio)
+	}
+	if total == 0 {
+		return nil, errors.New("ratios must not all be zero")
+	}
+
+	// Allocate each part rounding towards zero, then distribute the remainder
+	prices := make([]Price, 0, len(ratios))
+	remainder := p.Amount
+	for _, ratio := range ratios {
+		amount, err := mulDiv(p.Amount, int64(ratio), total)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, Price{Currency: p.Currency, Amount: amount})
+		remainder -= amount
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for idx := 0; remainder != 0; idx = (idx + 1) % len(prices) {
+		if ratios[idx] == 0 {
+			continue
+		}
+		prices[idx].Amount += step
+		remainder -= step
+	}
+
+	return prices, nil
+}
+
+// Cmp compares prices, returning -1 if the price is less than the other price,
+// 0 if they are equal, and +1 if the price is greater than the other price.
+//
+// Prices in different currencies cannot be compared without converting them (see Converter),
+// so Cmp only gives a consistent order of them for sorting, by ordering them by their currency code.
+// Use CheckedCmp to compare the values of prices that may be in different currencies.
+func (p Price) Cmp(other Price) int {
+	if p.Currency != other.Currency {
+		return cmp.Compare(p.Currency.Value, other.Currency.Value)
+	}
+	return cmp.Compare(p.Amount, other.Amount)
+}
+
+// CheckedCmp compares prices like Cmp, returning an error if their currencies do not match.
+//
+// A price with an unset currency can be compared to a price of any currency.
+func (p Price) CheckedCmp(other Price) (int, error) {
+	_, err := matchCurrencies(p, other)
+	if err != nil {
+		return 0, err
+	}
+	return cmp.Compare(p.Amount, other.Amount), nil
+}
+
+// Less returns whether the price is less than the other price.
+// Prices in different currencies are never less than each other. See CheckedCmp.
+func (p Price) Less(other Price) bool {
+	result, err := p.CheckedCmp(other)
+	return err == nil && result < 0
+}
+
+// Equal returns whether the price is equal to the other price,
+// including having the same currency.
+func (p Price) Equal(other Price) bool {
+	return p.Cmp(other) == 0
+}
+
+// matchCurrencies gets the currency of the result of arithmetic on two prices.
+// Returns an error if the currencies of the prices are both set, but do not match.
+func matchCurrencies(a, b Price) (Currency, error) {
+	switch {
+	case a.Currency == b.Currency || b.Currency.Value == "":
+		return a.Currency, nil
+	case a.Currency.Value == "":
+		return b.Currency, nil
+	default:
+		return Currency{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency.Value, b.Currency.Value)
+	}
+}
+
+// roundedDivide divides integers, rounding half away from zero.
+func roundedDivide(dividend, divisor int64) int64 {
+	quotient := dividend / divisor
+	remainder := dividend % divisor
+	if 2*absInt64(remainder) >= absInt64(divisor) {
+		if (dividend < 0) != (divisor < 0) {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return quotient
+}
+
+// mulDiv calculates a * b / c, rounding towards zero, without intermediate overflow.
+func mulDiv(a, b, c int64) (int64, error) {
+	result := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	result.Quo(result, big.NewInt(c))
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("%w: %d * %d / %d", ErrPriceOverflow, a, b, c)
+	}
+	return result.Int64(), nil
+}
+
+func absInt64(num int64) int64 {
+	if num < 0 {
+		return -num
+	}
+	return num
 }
 
 func priceString(cost float64, currency Currency) string {
-	costString := strconv.FormatFloat(cost, 'f', 2, 64)
+	costString := strconv.FormatFloat(cost, 'f', currency.Exponent(), 64)
 	currencyString := currency.Symbol()
 	if currencyString == "" {
 		return costString + currency.Value
@@ -78,24 +314,72 @@ func priceString(cost float64, currency Currency) string {
 var (
 	currency = enum.NewBuilder[string, Currency]()
 
+	CurrencyCHF = currency.Add(Currency{"CHF"})
+	CurrencyCZK = currency.Add(Currency{"CZK"})
+	CurrencyDKK = currency.Add(Currency{"DKK"})
+	CurrencyEUR = currency.Add(Currency{"EUR"})
 	CurrencyGBP = currency.Add(Currency{"GBP"})
+	CurrencyNOK = currency.Add(Currency{"NOK"})
+	CurrencyPLN = currency.Add(Currency{"PLN"})
+	CurrencySEK = currency.Add(Currency{"SEK"})
+	CurrencyUSD = currency.Add(Currency{"USD"})
 
 	Currencies = currency.Enum()
 )
 
+// Currency is a currency that tickets are sold in.
+//
+// Currencies decoded from the ticket listings feed may not be one of Currencies,
+// if Twickets has started selling tickets in a new currency. Use Currencies.Contains to check.
 type Currency enum.Member[string]
 
 // Symbol is the character that represents the currency
 // e.g. $, £, €.
 func (c Currency) Symbol() string {
 	switch c {
+	case CurrencyCHF:
+		return "CHF"
+	case CurrencyCZK:
+		return "Kč"
+	case CurrencyDKK, CurrencyNOK, CurrencySEK:
+		return "kr"
+	case CurrencyEUR:
+		return "€"
 	case CurrencyGBP:
 		return "£"
+	case CurrencyPLN:
+		return "zł"
+	case CurrencyUSD:
+		return "$"
 	default:
 		return ""
 	}
 }
 
+// currencyExponents are the ISO 4217 exponents of each currency.
+var currencyExponents = map[Currency]int{
+	CurrencyCHF: 2,
+	CurrencyCZK: 2,
+	CurrencyDKK: 2,
+	CurrencyEUR: 2,
+	CurrencyGBP: 2,
+	CurrencyNOK: 2,
+	CurrencyPLN: 2,
+	CurrencySEK: 2,
+	CurrencyUSD: 2,
+}
+
+// Exponent is the number of decimal places between the minor and major unit
+// of the currency, as defined by ISO 4217 e.g. 2 for GBP (100 pence in a pound).
+// Returns 2 for unknown currencies, as the ticket listings feed gives amounts in cents.
+func (c Currency) Exponent() int {
+	exponent, ok := currencyExponents[c]
+	if !ok {
+		return 2
+	}
+	return exponent
+}
+
 func (c *Currency) UnmarshalJSON(data []byte) error {
 	var currencyString string
 	err := json.Unmarshal(data, &currencyString)
@@ -103,6 +387,12 @@ func (c *Currency) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// An empty currency is unset
+	if currencyString == "" {
+		*c = Currency{}
+		return nil
+	}
+
 	currency := Currencies.Parse(currencyString)
 	if currency == nil {
 		return fmt.Errorf("currency '%s' is not valid", currencyString)
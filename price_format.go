@@ -0,0 +1,226 @@
+package twigots
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Languages that write the currency symbol after the amount e.g. 1.234,50 €
+var symbolSuffixLanguages = map[string]bool{
+	"cs": true,
+	"da": true,
+	"de": true,
+	"es": true,
+	"fi": true,
+	"fr": true,
+	"it": true,
+	"nb": true,
+	"nn": true,
+	"no": true,
+	"pl": true,
+	"pt": true,
+	"sk": true,
+	"sv": true,
+}
+
+// Languages that separate a currency symbol before the amount with a space e.g. € 1.234,50
+var symbolSpacedPrefixLanguages = map[string]bool{
+	"nl": true,
+}
+
+// Format the price for display in a locale, using the digit grouping, decimal separator
+// and currency symbol position of the locale's language.
+// e.g. £1,234.50 in en-GB, 1.234,50 € in de-DE or € 1.234,50 in nl-NL.
+//
+// Prices with an unknown currency are formatted using the currency code as the symbol.
+func (p Price) Format(locale language.Tag) string {
+	exponent := p.Currency.Exponent()
+	value := math.Abs(float64(p.Amount)) / math.Pow10(exponent)
+	numberString := message.NewPrinter(locale).Sprint(number.Decimal(value, number.Scale(exponent)))
+
+	symbol := p.Currency.Symbol()
+	if symbol == "" {
+		symbol = p.Currency.Value
+	}
+
+	var sign string
+	if p.Amount < 0 {
+		sign = "-"
+	}
+
+	base, _ := locale.Base()
+	switch {
+	case symbol == "":
+		return sign + numberString
+	case symbolSuffixLanguages[base.String()]:
+		return sign + numberString + " " + symbol
+	case symbolSpacedPrefixLanguages[base.String()] || endsWithLetter(symbol):
+		// Alphabetic symbols (e.g. CHF) are always separated from the amount
+		return sign + symbol + " " + numberString
+	default:
+		return sign + symbol + numberString
+	}
+}
+
+func endsWithLetter(s string) bool {
+	runes := []rune(s)
+	return len(runes) != 0 && unicode.IsLetter(runes[len(runes)-1])
+}
+
+// ParsePrice parses a money string e.g. £80, 80 GBP, 1.234,50 € or 80.
+//
+// The currency can be given as a symbol or currency code, either before or after the amount.
+// If no currency is given, the default currency is used (which can be unset).
+// Symbols shared by several currencies (e.g. kr) are only accepted if they are the
+// symbol of the default currency.
+//
+// Both . and , are accepted as the decimal separator. If only one separator is used
+// and it is followed by exactly three digits, it is treated as a thousands separator (e.g. 1,234).
+// Spaces and apostrophes can also be used as thousands separators.
+func ParsePrice(priceString string, defaultCurrency Currency) (Price, error) {
+	value := strings.TrimSpace(priceString)
+	if value == "" {
+		return Price{}, errors.New("price must be set")
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "-") {
+		negative = true
+		value = strings.TrimSpace(value[1:])
+	}
+
+	// Split into amount and currency
+	amountStart := strings.IndexFunc(value, isAmountRune)
+	amountEnd := strings.LastIndexFunc(value, isAmountRune)
+	if amountStart == -1 {
+		return Price{}, fmt.Errorf("price '%s' does not contain an amount", priceString)
+	}
+	amountString := value[amountStart : amountEnd+1]
+	prefix := strings.TrimSpace(value[:amountStart])
+	suffix := strings.TrimSpace(value[amountEnd+1:])
+	if prefix != "" && suffix != "" {
+		return Price{}, fmt.Errorf("price '%s' has text both before and after the amount", priceString)
+	}
+
+	// Negative sign can also come after a currency symbol e.g. £-5
+	if strings.HasPrefix(amountString, "-") && !negative {
+		negative = true
+		amountString = amountString[1:]
+	}
+
+	currency := defaultCurrency
+	if currencyString := prefix + suffix; currencyString != "" {
+		var err error
+		currency, err = parseCurrencyString(currencyString, defaultCurrency)
+		if err != nil {
+			return Price{}, fmt.Errorf("invalid price '%s': %w", priceString, err)
+		}
+	}
+
+	amount, err := parseAmount(amountString, currency.Exponent())
+	if err != nil {
+		return Price{}, fmt.Errorf("invalid price '%s': %w", priceString, err)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Price{Currency: currency, Amount: amount}, nil
+}
+
+// isAmountRune returns whether a rune can be part of an amount.
+// Non-breaking spaces are used as thousands separators in some locales.
+func isAmountRune(r rune) bool {
+	return unicode.IsDigit(r) || r == '.' || r == ',' || r == '-' || r == '\'' || r == '’' ||
+		r == ' ' || r == '\u00a0' || r == '\u202f'
+}
+
+// parseCurrencyString parses a currency code or symbol.
+func parseCurrencyString(currencyString string, defaultCurrency Currency) (Currency, error) {
+	upperCurrencyString := strings.ToUpper(currencyString)
+	if currency := Currencies.Parse(upperCurrencyString); currency != nil {
+		return *currency, nil
+	}
+
+	if defaultCurrency.Symbol() == currencyString {
+		return defaultCurrency, nil
+	}
+
+	var matches []Currency
+	for _, currency := range Currencies.Members() {
+		if currency.Symbol() == currencyString {
+			matches = append(matches, currency)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Currency{}, fmt.Errorf("unknown currency '%s'", currencyString)
+	case 1:
+		return matches[0], nil
+	default:
+		return Currency{}, fmt.Errorf("currency symbol '%s' is ambiguous, use a currency code instead", currencyString)
+	}
+}
+
+// parseAmount parses an amount as a number of minor units.
+func parseAmount(amountString string, exponent int) (int64, error) {
+	// Remove grouping spaces and apostrophes
+	amountString = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'', '’':
+			return -1
+		default:
+			return r
+		}
+	}, amountString)
+
+	// Determine decimal separator
+	integerPart, fractionalPart := amountString, ""
+	lastSeparator := strings.LastIndexAny(amountString, ".,")
+	if lastSeparator != -1 {
+		separator := amountString[lastSeparator]
+		otherSeparator := byte('.')
+		if separator == '.' {
+			otherSeparator = ','
+		}
+
+		isDecimal := strings.IndexByte(amountString, otherSeparator) != -1 ||
+			(strings.Count(amountString, string(separator)) == 1 && len(amountString)-lastSeparator-1 != 3)
+		if isDecimal {
+			integerPart = amountString[:lastSeparator]
+			fractionalPart = amountString[lastSeparator+1:]
+			if strings.IndexByte(integerPart, separator) != -1 {
+				return 0, fmt.Errorf("amount '%s' has more than one decimal separator", amountString)
+			}
+		}
+		integerPart = strings.NewReplacer(".", "", ",", "").Replace(integerPart)
+	}
+
+	if integerPart == "" && fractionalPart == "" {
+		return 0, errors.New("amount must be set")
+	}
+	if len(fractionalPart) > exponent {
+		return 0, fmt.Errorf("amount has more than %d decimal places", exponent)
+	}
+
+	digits := integerPart + fractionalPart + strings.Repeat("0", exponent-len(fractionalPart))
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return 0, fmt.Errorf("amount '%s' is not a number", amountString)
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount '%s' is too large", amountString)
+	}
+	return amount, nil
+}
@@ -0,0 +1,75 @@
+package twigots
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestPriceFormat(t *testing.T) {
+	tests := []struct {
+		price    Price
+		locale   language.Tag
+		expected string
+	}{
+		{Price{CurrencyGBP, 123450}, language.BritishEnglish, "£1,234.50"},
+		{Price{CurrencyGBP, -8000}, language.BritishEnglish, "-£80.00"},
+		{Price{CurrencyEUR, 123450}, language.German, "1.234,50 €"},
+		{Price{CurrencyEUR, 123450}, language.Dutch, "€ 1.234,50"},
+		{Price{CurrencyUSD, 99}, language.AmericanEnglish, "$0.99"},
+		{Price{CurrencyCHF, 123450}, language.English, "CHF 1,234.50"},
+		{Price{CurrencyPLN, 123450}, language.Polish, "1 234,50 zł"},
+		{Price{Currency{"XYZ"}, 500}, language.English, "XYZ 5.00"},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			require.Equal(t, test.expected, test.price.Format(test.locale))
+		})
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Price
+	}{
+		{"£80", Price{CurrencyGBP, 8000}},
+		{"80", Price{CurrencyGBP, 8000}},
+		{"80.5", Price{CurrencyGBP, 8050}},
+		{" 80 EUR ", Price{CurrencyEUR, 8000}},
+		{"usd 12.34", Price{CurrencyUSD, 1234}},
+		{"£1,234.50", Price{CurrencyGBP, 123450}},
+		{"1.234,50 €", Price{CurrencyEUR, 123450}},
+		{"1 234,50 zł", Price{CurrencyPLN, 123450}},
+		{"1,234", Price{CurrencyGBP, 123400}},
+		{"12,5", Price{CurrencyGBP, 1250}},
+		{"CHF 1'234.50", Price{CurrencyCHF, 123450}},
+		{"-£5", Price{CurrencyGBP, -500}},
+		{"£-5", Price{CurrencyGBP, -500}},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			price, err := ParsePrice(test.input, CurrencyGBP)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, price)
+		})
+	}
+
+	// Ambiguous symbols are only accepted if they are the symbol of the default currency
+	price, err := ParsePrice("30 kr", CurrencySEK)
+	require.NoError(t, err)
+	require.Equal(t, Price{CurrencySEK, 3000}, price)
+	_, err = ParsePrice("30 kr", CurrencyGBP)
+	require.Error(t, err)
+
+	// Default currency can be unset
+	price, err = ParsePrice("30", Currency{})
+	require.NoError(t, err)
+	require.Equal(t, Price{Amount: 3000}, price)
+
+	for _, input := range []string{"", "£", "abc", "£80p", "£80 GBP", "1.2.3,4,5", "ABC 80"} {
+		_, err := ParsePrice(input, CurrencyGBP)
+		require.Error(t, err, input)
+	}
+}
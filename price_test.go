@@ -2,6 +2,7 @@ package twigots
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -12,3 +13,137 @@ func TestCurrencyMarshalJSON(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `"GBP"`, string(data))
 }
+
+func TestCurrencySymbol(t *testing.T) {
+	require.Equal(t, "£", CurrencyGBP.Symbol())
+	require.Equal(t, "€", CurrencyEUR.Symbol())
+	require.Equal(t, "$", CurrencyUSD.Symbol())
+	require.Equal(t, "zł", CurrencyPLN.Symbol())
+	require.Equal(t, "kr", CurrencySEK.Symbol())
+	require.Empty(t, Currency{"XYZ"}.Symbol())
+
+	// Every currency should have a symbol
+	for _, currency := range Currencies.Members() {
+		require.NotEmpty(t, currency.Symbol(), currency.Value)
+	}
+}
+
+func TestCurrencyExponent(t *testing.T) {
+	require.Equal(t, 2, CurrencyGBP.Exponent())
+	require.Equal(t, 2, Currency{"XYZ"}.Exponent())
+	for _, currency := range Currencies.Members() {
+		_, ok := currencyExponents[currency]
+		require.True(t, ok, currency.Value)
+	}
+}
+
+func TestPriceCheckedAdd(t *testing.T) {
+	price, err := Price{CurrencyGBP, 1050}.CheckedAdd(Price{CurrencyGBP, 250})
+	require.NoError(t, err)
+	require.Equal(t, Price{CurrencyGBP, 1300}, price)
+
+	// Unset currencies are compatible with any currency
+	price, err = Price{}.CheckedAdd(Price{CurrencyEUR, 250})
+	require.NoError(t, err)
+	require.Equal(t, Price{CurrencyEUR, 250}, price)
+
+	_, err = Price{CurrencyGBP, 1050}.CheckedAdd(Price{CurrencyEUR, 250})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = Price{CurrencyGBP, math.MaxInt64}.CheckedAdd(Price{CurrencyGBP, 1})
+	require.ErrorIs(t, err, ErrPriceOverflow)
+}
+
+func TestPriceCheckedSubtract(t *testing.T) {
+	price, err := Price{CurrencyGBP, 1050}.CheckedSubtract(Price{CurrencyGBP, 250})
+	require.NoError(t, err)
+	require.Equal(t, Price{CurrencyGBP, 800}, price)
+
+	_, err = Price{CurrencyGBP, 1050}.CheckedSubtract(Price{CurrencyUSD, 250})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = Price{CurrencyGBP, math.MinInt64}.CheckedSubtract(Price{CurrencyGBP, 1})
+	require.ErrorIs(t, err, ErrPriceOverflow)
+}
+
+func TestPriceCheckedMultiply(t *testing.T) {
+	price, err := Price{CurrencyGBP, 1050}.CheckedMultiply(3)
+	require.NoError(t, err)
+	require.Equal(t, Price{CurrencyGBP, 3150}, price)
+
+	_, err = Price{CurrencyGBP, math.MaxInt64 / 2}.CheckedMultiply(3)
+	require.ErrorIs(t, err, ErrPriceOverflow)
+
+	_, err = Price{CurrencyGBP, math.MinInt64}.CheckedMultiply(-1)
+	require.ErrorIs(t, err, ErrPriceOverflow)
+}
+
+func TestPriceDivide(t *testing.T) {
+	require.Equal(t, Price{CurrencyGBP, 333}, Price{CurrencyGBP, 1000}.Divide(3))
+	require.Equal(t, Price{CurrencyGBP, 667}, Price{CurrencyGBP, 2000}.Divide(3))
+	require.Equal(t, Price{CurrencyGBP, -667}, Price{CurrencyGBP, -2000}.Divide(3))
+	require.Equal(t, Price{CurrencyGBP, 3}, Price{CurrencyGBP, 5}.Divide(2))
+
+	// Dividing by zero should not panic
+	require.Equal(t, Price{CurrencyGBP, 0}, Price{CurrencyGBP, 1000}.Divide(0))
+}
+
+func TestPriceSplit(t *testing.T) {
+	prices, err := Price{CurrencyGBP, 1000}.Split(3)
+	require.NoError(t, err)
+	require.Equal(t, []Price{{CurrencyGBP, 334}, {CurrencyGBP, 333}, {CurrencyGBP, 333}}, prices)
+
+	prices, err = Price{CurrencyGBP, -1000}.Split(3)
+	require.NoError(t, err)
+	require.Equal(t, []Price{{CurrencyGBP, -334}, {CurrencyGBP, -333}, {CurrencyGBP, -333}}, prices)
+
+	_, err = Price{CurrencyGBP, 1000}.Split(0)
+	require.Error(t, err)
+}
+
+func TestPriceAllocate(t *testing.T) {
+	prices, err := Price{CurrencyGBP, 1001}.Allocate(1, 0, 3)
+	require.NoError(t, err)
+	require.Equal(t, []Price{{CurrencyGBP, 251}, {CurrencyGBP, 0}, {CurrencyGBP, 750}}, prices)
+
+	// Should not overflow with large amounts
+	prices, err = Price{CurrencyGBP, math.MaxInt64}.Allocate(1, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64), prices[0].Amount+prices[1].Amount)
+
+	_, err = Price{CurrencyGBP, 1000}.Allocate()
+	require.Error(t, err)
+	_, err = Price{CurrencyGBP, 1000}.Allocate(0, 0)
+	require.Error(t, err)
+	_, err = Price{CurrencyGBP, 1000}.Allocate(1, -1)
+	require.Error(t, err)
+}
+
+func TestPriceCmp(t *testing.T) {
+	require.Equal(t, -1, Price{CurrencyGBP, 100}.Cmp(Price{CurrencyGBP, 200}))
+	require.Equal(t, 0, Price{CurrencyGBP, 200}.Cmp(Price{CurrencyGBP, 200}))
+	require.Equal(t, 1, Price{CurrencyGBP, 300}.Cmp(Price{CurrencyGBP, 200}))
+
+	require.True(t, Price{CurrencyGBP, 100}.Less(Price{CurrencyGBP, 200}))
+	require.True(t, Price{CurrencyGBP, 200}.Equal(Price{CurrencyGBP, 200}))
+
+	// Different currencies are ordered by currency code, but are not less than each other
+	require.Equal(t, -1, Price{CurrencyEUR, 300}.Cmp(Price{CurrencyGBP, 200}))
+	require.False(t, Price{CurrencyGBP, 200}.Equal(Price{CurrencyEUR, 200}))
+	require.False(t, Price{CurrencyEUR, 300}.Less(Price{CurrencyGBP, 200}))
+	require.False(t, Price{CurrencyGBP, 200}.Less(Price{CurrencyEUR, 300}))
+}
+
+func TestPriceCheckedCmp(t *testing.T) {
+	result, err := Price{CurrencyGBP, 100}.CheckedCmp(Price{CurrencyGBP, 200})
+	require.NoError(t, err)
+	require.Equal(t, -1, result)
+
+	// Unset currencies can be compared to any currency
+	result, err = Price{CurrencyGBP, 300}.CheckedCmp(Price{Amount: 200})
+	require.NoError(t, err)
+	require.Equal(t, 1, result)
+
+	_, err = Price{CurrencyEUR, 300}.CheckedCmp(Price{CurrencyGBP, 200})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+}
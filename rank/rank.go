@@ -0,0 +1,109 @@
+package rank
+
+import (
+	"sort"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// ComponentScore is the score given to a ticket listing by a single scorer.
+type ComponentScore struct {
+	Name   string
+	Weight float64
+
+	// Score is a float between 0 and 1, before any weighting is applied.
+	Score float64
+}
+
+// RankedTicketListing is a ticket listing with its overall score and a breakdown of the
+// scores given to it by each scorer.
+type RankedTicketListing struct {
+	Listing twigots.TicketListing
+
+	// Score is the weighted average of all component scores.
+	// It is a float between 0 and 1 (with 1 being the best possible score).
+	Score float64
+
+	Components []ComponentScore
+}
+
+// Component gets the component score with the specified name, or returns nil if one does not exist.
+func (l RankedTicketListing) Component(name string) *ComponentScore {
+	for _, component := range l.Components {
+		if component.Name == name {
+			return &component
+		}
+	}
+	return nil
+}
+
+// RankTicketListings scores ticket listings using the provided scorers, returning them
+// sorted from best (highest score) to worst (lowest score).
+//
+// The overall score of a listing is the weighted average of the scores of all scorers.
+// Scorers with a weight <= 0 are ignored. Listings with equal scores keep their original order.
+//
+// If no scorers are provided, all listings will have a score of 0 and keep their original order.
+func RankTicketListings(
+	listings []twigots.TicketListing,
+	scorers ...Scorer,
+) []RankedTicketListing {
+	rankedListings := make([]RankedTicketListing, 0, len(listings))
+	for _, listing := range listings {
+		rankedListings = append(rankedListings, ScoreTicketListing(listing, scorers...))
+	}
+
+	sort.SliceStable(rankedListings, func(i, j int) bool {
+		return rankedListings[i].Score > rankedListings[j].Score
+	})
+
+	return rankedListings
+}
+
+// TopTicketListings gets the best n ticket listings as scored by the provided scorers.
+//
+// If n is <= 0 or greater than the number of listings, all listings will be returned.
+// See RankTicketListings for details on how listings are ranked.
+func TopTicketListings(
+	listings []twigots.TicketListing,
+	n int,
+	scorers ...Scorer,
+) []RankedTicketListing {
+	rankedListings := RankTicketListings(listings, scorers...)
+	if n <= 0 || n > len(rankedListings) {
+		return rankedListings
+	}
+	return rankedListings[:n]
+}
+
+// ScoreTicketListing scores a single ticket listing using the provided scorers.
+func ScoreTicketListing(listing twigots.TicketListing, scorers ...Scorer) RankedTicketListing {
+	components := make([]ComponentScore, 0, len(scorers))
+	var weightedScoreSum, weightSum float64
+	for _, scorer := range scorers {
+		if scorer.Weight <= 0 || scorer.Score == nil {
+			continue
+		}
+
+		score := clamp(scorer.Score(listing))
+		components = append(components, ComponentScore{
+			Name:   scorer.Name,
+			Weight: scorer.Weight,
+			Score:  score,
+		})
+
+		weightedScoreSum += score * scorer.Weight
+		weightSum += scorer.Weight
+	}
+
+	var score float64
+	if weightSum > 0 {
+		score = weightedScoreSum / weightSum
+	}
+
+	return RankedTicketListing{
+		Listing:    listing,
+		Score:      score,
+		Components: components,
+	}
+}
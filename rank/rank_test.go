@@ -0,0 +1,93 @@
+package rank
+
+import (
+	"testing"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankTicketListings(t *testing.T) {
+	listings := []twigots.TicketListing{
+		testListing("Hamilton", 2, 100, 100), // No discount (only fee)
+		testListing("Coldplay", 2, 50, 100),  // 45% discount
+		testListing("Coldplay", 4, 50, 100),  // 45% discount, but too many tickets
+	}
+
+	rankedListings := RankTicketListings(
+		listings,
+		EventName("Coldplay", 2),
+		Discount(1),
+		NumTickets(2, 1),
+	)
+	require.Len(t, rankedListings, 3)
+
+	// Exact name match, good discount and exact number of tickets should be best
+	require.Equal(t, "Coldplay", rankedListings[0].Listing.Event.Name)
+	require.Equal(t, 2, rankedListings[0].Listing.NumTickets)
+	require.Equal(t, "Coldplay", rankedListings[1].Listing.Event.Name)
+	require.Equal(t, 4, rankedListings[1].Listing.NumTickets)
+	require.Equal(t, "Hamilton", rankedListings[2].Listing.Event.Name)
+
+	// Check breakdown
+	bestListing := rankedListings[0]
+	require.Len(t, bestListing.Components, 3)
+	require.InDelta(t, 1, bestListing.Component("eventName").Score, 0.001)
+	require.InDelta(t, 0.45, bestListing.Component("discount").Score, 0.001)
+	require.InDelta(t, 1, bestListing.Component("numTickets").Score, 0.001)
+	require.InDelta(t, (2*1+0.45+1)/4.0, bestListing.Score, 0.001)
+	require.Nil(t, bestListing.Component("distance"))
+}
+
+func TestTopTicketListings(t *testing.T) {
+	listings := []twigots.TicketListing{
+		testListing("A", 1, 90, 100),
+		testListing("B", 1, 50, 100),
+		testListing("C", 1, 70, 100),
+		testListing("D", 1, 10, 100),
+	}
+
+	topListings := TopTicketListings(listings, 2, Discount(1))
+	require.Len(t, topListings, 2)
+	require.Equal(t, "D", topListings[0].Listing.Event.Name)
+	require.Equal(t, "B", topListings[1].Listing.Event.Name)
+
+	// n larger than number of listings should return all listings
+	topListings = TopTicketListings(listings, 10, Discount(1))
+	require.Len(t, topListings, 4)
+}
+
+func TestRankTicketListingsNoScorers(t *testing.T) {
+	listings := []twigots.TicketListing{
+		testListing("A", 1, 10, 100),
+		testListing("B", 1, 20, 100),
+	}
+
+	rankedListings := RankTicketListings(listings)
+	require.Len(t, rankedListings, 2)
+	require.Equal(t, "A", rankedListings[0].Listing.Event.Name)
+	require.Equal(t, "B", rankedListings[1].Listing.Event.Name)
+	require.Zero(t, rankedListings[0].Score)
+	require.Empty(t, rankedListings[0].Components)
+}
+
+// testListing creates a test listing. Prices are specified in pounds.
+// The twickets fee is 10% of the total price excluding fee.
+func testListing(eventName string, numTickets, totalPrice, originalTotalPrice int) twigots.TicketListing {
+	return twigots.TicketListing{
+		Event:      twigots.Event{Name: eventName},
+		NumTickets: numTickets,
+		TotalPriceExclFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 100,
+		},
+		TwicketsFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 10,
+		},
+		OriginalTotalPrice: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(originalTotalPrice) * 100,
+		},
+	}
+}
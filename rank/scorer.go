@@ -0,0 +1,146 @@
+package rank
+
+import (
+	"math"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+)
+
+// Earth radius in kilometres, used for distance calculations
+const earthRadiusKm = 6371
+
+// ScoreFunc is a function that scores a TicketListing.
+//
+// Scores should be a float between 0 and 1 (with 0 being the worst possible score
+// and 1 being the best possible score). Scores outside this range will be clamped.
+type ScoreFunc func(twigots.TicketListing) float64
+
+// Scorer is a named and weighted function that scores a TicketListing.
+//
+// The weight determines how much the score contributes to the overall score
+// of a listing, relative to the weights of other scorers.
+type Scorer struct {
+	Name   string
+	Weight float64
+	Score  ScoreFunc
+}
+
+// NewScorer creates a custom scorer with the specified name, weight and score function.
+// Use this to plug in your own scoring logic.
+func NewScorer(name string, weight float64, score ScoreFunc) Scorer {
+	return Scorer{
+		Name:   name,
+		Weight: weight,
+		Score:  score,
+	}
+}
+
+// Discount creates a scorer that scores ticket listings by their discount.
+//
+// A listing with no discount (or a negative discount) will score 0,
+// and a listing that is free (100% off) will score 1.
+func Discount(weight float64) Scorer {
+	return NewScorer("discount", weight, func(listing twigots.TicketListing) float64 {
+		return listing.Discount()
+	})
+}
+
+// EventName creates a scorer that scores ticket listings by how similar their
+// event name is to the one specified.
+//
+// See filter.EventNameSimilarity for details on how similarity is calculated.
+func EventName(eventName string, weight float64) Scorer {
+	return NewScorer("eventName", weight, func(listing twigots.TicketListing) float64 {
+		return filter.EventNameSimilarity(eventName, listing.Event.Name)
+	})
+}
+
+// Distance creates a scorer that scores ticket listings by how close their event venue
+// is to the specified latitude and longitude.
+//
+// A venue at the specified location will score 1, with the score decreasing linearly to 0
+// for venues that are maxDistanceKm or further away.
+// Listings with venues without a known location will score 0.
+func Distance(latitude, longitude, maxDistanceKm, weight float64) Scorer {
+	return NewScorer("distance", weight, func(listing twigots.TicketListing) float64 {
+		location := listing.Event.Venue.Location
+		if location.Latitude == 0 && location.Longitude == 0 {
+			return 0
+		}
+		if maxDistanceKm <= 0 {
+			return 0
+		}
+
+		distance := haversineDistanceKm(latitude, longitude, location.Latitude, location.Longitude)
+		return 1 - distance/maxDistanceKm
+	})
+}
+
+// NumTickets creates a scorer that scores ticket listings by how close their number
+// of tickets is to the desired number of tickets.
+//
+// A listing with exactly the desired number of tickets will score 1. A listing with
+// fewer tickets than desired will score 0, as it cannot satisfy the desired quantity.
+// A listing with more tickets than desired will score lower the more extra tickets it has.
+func NumTickets(desiredNumTickets int, weight float64) Scorer {
+	return NewScorer("numTickets", weight, func(listing twigots.TicketListing) float64 {
+		if desiredNumTickets <= 0 || listing.NumTickets < desiredNumTickets {
+			return 0
+		}
+		return float64(desiredNumTickets) / float64(listing.NumTickets)
+	})
+}
+
+// TimeToEvent creates a scorer that scores ticket listings by how soon their event starts.
+//
+// An event starting now will score 1, with the score decreasing linearly to 0 for events
+// starting horizon or further in the future. Events that have already started will score 0.
+func TimeToEvent(horizon time.Duration, weight float64) Scorer {
+	return timeToEvent(horizon, weight, time.Now)
+}
+
+func timeToEvent(horizon time.Duration, weight float64, now func() time.Time) Scorer {
+	return NewScorer("timeToEvent", weight, func(listing twigots.TicketListing) float64 {
+		if horizon <= 0 {
+			return 0
+		}
+
+		timeToEvent := listing.Event.StartTime().Sub(now())
+		if timeToEvent < 0 {
+			return 0
+		}
+		return 1 - float64(timeToEvent)/float64(horizon)
+	})
+}
+
+// haversineDistanceKm calculates the great-circle distance between two points in kilometres.
+// See: https://en.wikipedia.org/wiki/Haversine_formula
+func haversineDistanceKm(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	latitude1Radians := degreesToRadians(latitude1)
+	latitude2Radians := degreesToRadians(latitude2)
+	latitudeDelta := degreesToRadians(latitude2 - latitude1)
+	longitudeDelta := degreesToRadians(longitude2 - longitude1)
+
+	a := math.Pow(math.Sin(latitudeDelta/2), 2) +
+		math.Cos(latitude1Radians)*math.Cos(latitude2Radians)*math.Pow(math.Sin(longitudeDelta/2), 2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusKm * c
+}
+
+func degreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// clamp a score to between 0 and 1.
+func clamp(score float64) float64 {
+	if math.IsNaN(score) || score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
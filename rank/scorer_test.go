@@ -0,0 +1,53 @@
+package rank
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistanceScorer(t *testing.T) {
+	// London Stadium and Twickenham are ~20km apart
+	londonStadium := twigots.Location{Latitude: 51.517, Longitude: -0.105}
+	twickenham := twigots.Location{Latitude: 51.448, Longitude: -0.33667}
+
+	scorer := Distance(londonStadium.Latitude, londonStadium.Longitude, 100, 1)
+
+	listing := twigots.TicketListing{}
+	listing.Event.Venue.Location = londonStadium
+	require.InDelta(t, 1, scorer.Score(listing), 0.001)
+
+	listing.Event.Venue.Location = twickenham
+	require.InDelta(t, 0.82, scorer.Score(listing), 0.01)
+
+	// Unknown location
+	listing.Event.Venue.Location = twigots.Location{}
+	require.Zero(t, scorer.Score(listing))
+}
+
+func TestNumTicketsScorer(t *testing.T) {
+	scorer := NumTickets(2, 1)
+	require.Zero(t, scorer.Score(twigots.TicketListing{NumTickets: 1}))
+	require.InDelta(t, 1, scorer.Score(twigots.TicketListing{NumTickets: 2}), 0.001)
+	require.InDelta(t, 0.5, scorer.Score(twigots.TicketListing{NumTickets: 4}), 0.001)
+}
+
+func TestTimeToEventScorer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	scorer := timeToEvent(10*24*time.Hour, 1, func() time.Time { return now })
+
+	listing := twigots.TicketListing{}
+	listing.Event.Date = twigots.Date{Time: time.Date(2024, 6, 6, 0, 0, 0, 0, time.UTC)}
+	listing.Event.Time = twigots.Time{Time: time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC)}
+	require.InDelta(t, 0.5, scorer.Score(listing), 0.001)
+
+	// Event already started
+	listing.Event.Date = twigots.Date{Time: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	require.Zero(t, clamp(scorer.Score(listing)))
+
+	// Event beyond horizon
+	listing.Event.Date = twigots.Date{Time: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}
+	require.Zero(t, clamp(scorer.Score(listing)))
+}
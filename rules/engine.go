@@ -0,0 +1,418 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/notify"
+)
+
+// Engine evaluates alert rules against ticket listings, sending alerts to the sinks of matching rules.
+//
+// For each rule, a matching listing is:
+//   - dropped if an alert with the same cooldown key was sent within the rule cooldown
+//   - sent immediately if the rule is not a digest rule, it is not quiet hours,
+//     and the maximum number of alerts per hour has not been reached
+//   - otherwise, kept pending and sent in a single alert by a later Flush
+//
+// Alerts are sent without holding the engine lock, and to each sink of a rule separately.
+// If an alert is only partly sent (e.g. a sink fails, or returns a *notify.PartialSendError),
+// the listings that were not sent to a sink are only retried to that sink, with the next alert of the rule.
+type Engine struct {
+	rules []compiledRule
+	now   func() time.Time
+
+	mutex sync.Mutex
+	state *State
+
+	// sending are the listings of immediate alerts being sent, keyed by rule name.
+	// Rules that are sending alerts do not send further alerts until sending has finished.
+	sending map[string][]twigots.TicketListing
+}
+
+// NewEngine creates an engine evaluating the specified rules.
+// If state is nil, an empty state is used. The state is modified as rules are evaluated.
+func NewEngine(rules []Rule, state *State) (*Engine, error) {
+	if state == nil {
+		state = NewState()
+	}
+	if state.Rules == nil {
+		state.Rules = make(map[string]*RuleState)
+	}
+
+	compiledRules, err := compileRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Engine{
+		rules:   compiledRules,
+		now:     time.Now,
+		state:   state,
+		sending: make(map[string][]twigots.TicketListing),
+	}, nil
+}
+
+// SetRules replaces the rules evaluated by the engine.
+// The state of rules is kept by name, so rules that are kept keep their cooldowns and pending listings.
+func (e *Engine) SetRules(rules []Rule) error {
+	compiledRules, err := compileRules(rules)
+	if err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rules = compiledRules
+	return nil
+}
+
+// compileRules compiles rules, checking their names are unique.
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	compiledRules := make([]compiledRule, 0, len(rules))
+	ruleNames := make(map[string]struct{}, len(rules))
+	for i, rule := range rules {
+		compiledRule, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i+1, err)
+		}
+
+		if _, ok := ruleNames[rule.Name]; ok {
+			return nil, fmt.Errorf("invalid rule %d: name '%s' is not unique", i+1, rule.Name)
+		}
+		ruleNames[rule.Name] = struct{}{}
+
+		compiledRules = append(compiledRules, compiledRule)
+	}
+
+	return compiledRules, nil
+}
+
+// Process evaluates all rules against ticket listings, sending any alerts, then flushes pending listings.
+// Alerts of different rules are sent concurrently. Errors sending alerts do not stop other rules
+// being evaluated, and are joined and returned.
+func (e *Engine) Process(ctx context.Context, listings ...twigots.TicketListing) error {
+	now, deliveries := e.plan(listings)
+	return e.deliver(ctx, now, deliveries)
+}
+
+// Flush sends pending listings of all rules that are able to send an alert,
+// such as digest rules whose digest interval has elapsed, or rules whose quiet hours have ended.
+// Flush should be called periodically (see Run) so pending listings are sent even if no new listings are processed.
+func (e *Engine) Flush(ctx context.Context) error {
+	now, deliveries := e.plan(nil)
+	return e.deliver(ctx, now, deliveries)
+}
+
+// delivery is the alerts of a rule to send.
+// Deliveries are planned while the engine is locked, and sent while it is unlocked.
+type delivery struct {
+	rule compiledRule
+
+	// immediate are listings that are each sent in their own alert.
+	immediate []twigots.TicketListing
+
+	// pending are pending listings sent in a single alert, along with unsent listings.
+	// These are kept in the rule state until they have been sent.
+	pending []twigots.TicketListing
+
+	// unsent are listings previously not sent to a sink, keyed by sink index.
+	unsent map[int][]twigots.TicketListing
+
+	// flush is whether pending and unsent listings are sent.
+	flush bool
+}
+
+// plan evaluates all rules against ticket listings, returning the time of evaluation and the alerts to send.
+// Rules with alerts to send are marked as sending until the delivery has been recorded.
+func (e *Engine) plan(listings []twigots.TicketListing) (time.Time, []delivery) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := e.now()
+	var deliveries []delivery
+	for _, rule := range e.rules {
+		delivery := e.planRule(rule, now, listings)
+		if len(delivery.immediate) != 0 || delivery.flush {
+			e.sending[rule.Name] = delivery.immediate
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return now, deliveries
+}
+
+func (e *Engine) planRule(rule compiledRule, now time.Time, listings []twigots.TicketListing) delivery {
+	ruleState := e.state.rule(rule.Name)
+	ruleState.prune(now, time.Duration(rule.Cooldown))
+
+	sendingListings, sending := e.sending[rule.Name]
+	delivery := delivery{rule: rule}
+	for _, listing := range listings {
+		if !rule.predicate(listing) {
+			continue
+		}
+
+		if isCoolingDown(rule, ruleState, listing, sendingListings, delivery.immediate) {
+			continue
+		}
+
+		if sending || !canSendImmediately(rule, ruleState, now, len(delivery.immediate)) {
+			ruleState.Pending = append(ruleState.Pending, listing)
+			continue
+		}
+
+		delivery.immediate = append(delivery.immediate, listing)
+	}
+
+	if !sending && canFlush(rule, ruleState, now, len(delivery.immediate)) {
+		delivery.flush = true
+		delivery.pending = slices.Clone(ruleState.Pending)
+		delivery.unsent = make(map[int][]twigots.TicketListing, len(ruleState.Unsent))
+		for sinkIdx, unsent := range ruleState.Unsent {
+			delivery.unsent[sinkIdx] = slices.Clone(unsent)
+		}
+	}
+
+	return delivery
+}
+
+func canSendImmediately(rule compiledRule, ruleState *RuleState, now time.Time, numAlerts int) bool {
+	return !rule.Digest &&
+		len(ruleState.Pending) == 0 &&
+		!isQuietHours(rule, now) &&
+		!isThrottled(rule, ruleState, numAlerts)
+}
+
+// canFlush returns whether the pending and unsent listings of a rule can be sent in a single alert.
+// numAlerts is the number of alerts that are about to be sent.
+func canFlush(rule compiledRule, ruleState *RuleState, now time.Time, numAlerts int) bool {
+	if (len(ruleState.Pending) == 0 && len(ruleState.Unsent) == 0) ||
+		isQuietHours(rule, now) ||
+		isThrottled(rule, ruleState, numAlerts) {
+		return false
+	}
+
+	if rule.Digest {
+		// The first digest is sent one digest interval after the rule is first used
+		if ruleState.LastDigestAt.IsZero() {
+			ruleState.LastDigestAt = now
+		}
+		if now.Sub(ruleState.LastDigestAt) < time.Duration(rule.DigestInterval) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deliver sends alerts, then records the results. Alerts of different rules are sent concurrently.
+func (e *Engine) deliver(ctx context.Context, now time.Time, deliveries []delivery) error {
+	errs := make([]error, len(deliveries))
+	var waitGroup sync.WaitGroup
+	for idx, delivery := range deliveries {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := e.deliverRule(ctx, now, delivery)
+			if err != nil {
+				errs[idx] = fmt.Errorf("rule '%s': %w", delivery.rule.Name, err)
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	return errors.Join(errs...)
+}
+
+// deliverRule sends the alerts of a rule without holding the engine lock, then records the results.
+//
+// Cooldowns and throttling are only recorded once an alert has been sent to at least one sink,
+// so a failed alert does not suppress later alerts. Listings of alerts that were not sent to any sink
+// are kept pending if they were pending, and are otherwise dropped.
+func (e *Engine) deliverRule(ctx context.Context, now time.Time, delivery delivery) error {
+	var errs []error
+	immediateResults := make([]sendResult, 0, len(delivery.immediate))
+	for _, listing := range delivery.immediate {
+		result := sendToSinks(ctx, delivery.rule.sinks, nil, []twigots.TicketListing{listing})
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+		immediateResults = append(immediateResults, result)
+	}
+
+	var flushResult sendResult
+	if delivery.flush {
+		flushResult = sendToSinks(ctx, delivery.rule.sinks, delivery.unsent, delivery.pending)
+		if flushResult.err != nil {
+			errs = append(errs, flushResult.err)
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	delete(e.sending, delivery.rule.Name)
+
+	ruleState := e.state.rule(delivery.rule.Name)
+	unsent := ruleState.Unsent
+	if delivery.flush && flushResult.sent {
+		if delivery.rule.Digest {
+			ruleState.LastDigestAt = now
+		}
+		ruleState.AlertedAt = append(ruleState.AlertedAt, now)
+		recordCooldown(delivery.rule, ruleState, now, delivery.pending...)
+
+		// Listings may have been added to pending while sending
+		ruleState.Pending = slices.Clone(ruleState.Pending[len(delivery.pending):])
+		unsent = flushResult.unsent
+	}
+
+	for idx, listing := range delivery.immediate {
+		result := immediateResults[idx]
+		if !result.sent {
+			continue
+		}
+		ruleState.AlertedAt = append(ruleState.AlertedAt, now)
+		recordCooldown(delivery.rule, ruleState, now, listing)
+		for sinkIdx, sinkUnsent := range result.unsent {
+			if unsent == nil {
+				unsent = make(map[int][]twigots.TicketListing)
+			}
+			unsent[sinkIdx] = append(unsent[sinkIdx], sinkUnsent...)
+		}
+	}
+	ruleState.Unsent = unsent
+
+	return errors.Join(errs...)
+}
+
+// sendResult is the result of sending an alert to the sinks of a rule.
+type sendResult struct {
+	// sent is whether the alert was sent to at least one sink.
+	sent bool
+
+	// unsent are the listings that were not sent to each sink, keyed by sink index.
+	unsent map[int][]twigots.TicketListing
+
+	err error
+}
+
+// sendToSinks sends an alert of listings to each sink, along with the listings previously unsent to the sink.
+// Sinks without any listings to send are skipped.
+func sendToSinks(
+	ctx context.Context,
+	sinks []notify.Sink,
+	unsent map[int][]twigots.TicketListing,
+	listings []twigots.TicketListing,
+) sendResult {
+	var result sendResult
+	var errs []error
+	for sinkIdx, sink := range sinks {
+		sinkListings := slices.Concat(unsent[sinkIdx], listings)
+		if len(sinkListings) == 0 {
+			continue
+		}
+
+		err := sink.Send(ctx, sinkListings...)
+		if err == nil {
+			result.sent = true
+			continue
+		}
+		errs = append(errs, err)
+
+		sinkUnsent := sinkListings
+		var partialErr *notify.PartialSendError
+		if errors.As(err, &partialErr) {
+			sinkUnsent = partialErr.Unsent
+		}
+		if len(sinkUnsent) < len(sinkListings) {
+			result.sent = true
+		}
+		if result.unsent == nil {
+			result.unsent = make(map[int][]twigots.TicketListing)
+		}
+		result.unsent[sinkIdx] = sinkUnsent
+	}
+	result.err = errors.Join(errs...)
+
+	return result
+}
+
+// isCoolingDown returns whether a listing should be dropped as an alert with the same cooldown key
+// has been sent within the rule cooldown, or a listing with the same cooldown key is already pending
+// or in one of the other listings about to be alerted about.
+func isCoolingDown(
+	rule compiledRule,
+	ruleState *RuleState,
+	listing twigots.TicketListing,
+	otherListings ...[]twigots.TicketListing,
+) bool {
+	cooldownKey := rule.CooldownScope.cooldownKey(listing)
+	if _, ok := ruleState.LastAlertedAt[cooldownKey]; ok {
+		return true
+	}
+	if rule.Cooldown <= 0 {
+		return false
+	}
+	for _, listings := range append(otherListings, ruleState.Pending) {
+		for _, otherListing := range listings {
+			if rule.CooldownScope.cooldownKey(otherListing) == cooldownKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordCooldown records that alerts about listings have been sent, starting their cooldown.
+func recordCooldown(rule compiledRule, ruleState *RuleState, now time.Time, listings ...twigots.TicketListing) {
+	if rule.Cooldown <= 0 {
+		return
+	}
+	for _, listing := range listings {
+		ruleState.LastAlertedAt[rule.CooldownScope.cooldownKey(listing)] = now
+	}
+}
+
+// Run flushes pending listings every interval until the context is cancelled.
+// Any errors flushing are passed to onError, which can be nil.
+//
+// Returns the context error once the context is cancelled.
+func (e *Engine) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			err := e.Flush(ctx)
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// SaveState saves the current state to a file. See State.Save.
+func (e *Engine) SaveState(path string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.state.Save(path)
+}
+
+func isQuietHours(rule compiledRule, now time.Time) bool {
+	return rule.quietHours != nil && rule.quietHours.contains(now)
+}
+
+// isThrottled returns whether the maximum number of alerts per hour of a rule has been reached,
+// including numAlerts alerts that are about to be sent.
+func isThrottled(rule compiledRule, ruleState *RuleState, numAlerts int) bool {
+	return rule.MaxAlertsPerHour > 0 && len(ruleState.AlertedAt)+numAlerts >= rule.MaxAlertsPerHour
+}
@@ -0,0 +1,433 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+	"github.com/ahobsonsayers/twigots/notify"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink records the ids of listings in each alert.
+// If err is set, alerts fail and are not recorded. Listings with an id in failIds
+// are not sent, and are returned in a *notify.PartialSendError.
+type recordingSink struct {
+	alerts  [][]string
+	err     error
+	failIds map[string]bool
+}
+
+func (s *recordingSink) Send(_ context.Context, listings ...twigots.TicketListing) error {
+	if s.err != nil {
+		return s.err
+	}
+	ids := make([]string, 0, len(listings))
+	var unsent []twigots.TicketListing
+	for _, listing := range listings {
+		if s.failIds[listing.Id] {
+			unsent = append(unsent, listing)
+			continue
+		}
+		ids = append(ids, listing.Id)
+	}
+	s.alerts = append(s.alerts, ids)
+	if len(unsent) != 0 {
+		return &notify.PartialSendError{Unsent: unsent, Err: errors.New("failed to send")}
+	}
+	return nil
+}
+
+// blockingSink blocks sending until unblocked, signalling when sending starts.
+type blockingSink struct {
+	started   chan struct{}
+	unblocked chan struct{}
+}
+
+func (s *blockingSink) Send(ctx context.Context, _ ...twigots.TicketListing) error {
+	s.started <- struct{}{}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.unblocked:
+		return nil
+	}
+}
+
+func newTestEngine(t *testing.T, rule Rule, state *State, now *time.Time) (*Engine, *recordingSink) {
+	sink := &recordingSink{}
+	rule.Sink = sink
+
+	engine, err := NewEngine([]Rule{rule}, state)
+	require.NoError(t, err)
+	engine.now = func() time.Time { return *now }
+
+	return engine, sink
+}
+
+func testListing(id, eventId, eventName string) twigots.TicketListing {
+	return twigots.TicketListing{
+		Id:         id,
+		NumTickets: 2,
+		Event:      twigots.Event{Id: eventId, Name: eventName},
+	}
+}
+
+func TestEngineFilter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:   "coldplay",
+		Filter: filter.Config{Event: "Coldplay"},
+	}, nil, &now)
+
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event2", "Hamilton"),
+		testListing("3", "event1", "Coldplay"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"3"}}, sink.alerts)
+}
+
+func TestEngineCooldown(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:     "coldplay",
+		Cooldown: Duration(30 * time.Minute),
+	}, nil, &now)
+
+	// Only the first listing for each event should be alerted about
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event1", "Coldplay"),
+		testListing("3", "event2", "Hamilton"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"3"}}, sink.alerts)
+
+	// Relisting during cooldown should be dropped
+	now = now.Add(10 * time.Minute)
+	err = engine.Process(context.Background(), testListing("4", "event1", "Coldplay"))
+	require.NoError(t, err)
+	require.Len(t, sink.alerts, 2)
+
+	// Relisting after cooldown should be alerted about
+	now = now.Add(30 * time.Minute)
+	err = engine.Process(context.Background(), testListing("5", "event1", "Coldplay"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"5"}, sink.alerts[2])
+}
+
+func TestEngineListingCooldown(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:          "coldplay",
+		Cooldown:      Duration(time.Hour),
+		CooldownScope: CooldownScopeListing,
+	}, nil, &now)
+
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event1", "Coldplay"),
+		testListing("1", "event1", "Coldplay"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"2"}}, sink.alerts)
+}
+
+func TestEngineMaxAlertsPerHour(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:             "all",
+		MaxAlertsPerHour: 2,
+	}, nil, &now)
+
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event2", "Hamilton"),
+		testListing("3", "event3", "Oasis"),
+		testListing("4", "event4", "Blur"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"2"}}, sink.alerts)
+
+	// Throttled listings should be sent in a single alert once the hour has passed
+	now = now.Add(time.Hour)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"2"}, {"3", "4"}}, sink.alerts)
+}
+
+func TestEngineQuietHours(t *testing.T) {
+	// 23:00 in London
+	now := time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name: "all",
+		QuietHours: &QuietHours{
+			Start:    "22:00",
+			End:      "07:00",
+			TimeZone: "Europe/London",
+		},
+	}, nil, &now)
+
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event2", "Hamilton"),
+	)
+	require.NoError(t, err)
+	require.Empty(t, sink.alerts)
+
+	// 06:30 in London, still quiet hours
+	now = time.Date(2024, 6, 2, 5, 30, 0, 0, time.UTC)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, sink.alerts)
+
+	// 07:00 in London, quiet hours have ended
+	now = time.Date(2024, 6, 2, 6, 0, 0, 0, time.UTC)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1", "2"}}, sink.alerts)
+}
+
+func TestEngineDigest(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:           "all",
+		Digest:         true,
+		DigestInterval: Duration(time.Hour),
+	}, nil, &now)
+
+	// First digest is sent one interval after the rule is first used
+	err := engine.Process(context.Background(), testListing("1", "event1", "Coldplay"))
+	require.NoError(t, err)
+	require.Empty(t, sink.alerts)
+
+	now = now.Add(20 * time.Minute)
+	err = engine.Process(context.Background(), testListing("2", "event2", "Hamilton"))
+	require.NoError(t, err)
+	now = now.Add(20 * time.Minute)
+	err = engine.Process(context.Background(), testListing("3", "event3", "Oasis"))
+	require.NoError(t, err)
+	require.Empty(t, sink.alerts)
+
+	now = now.Add(20 * time.Minute)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1", "2", "3"}}, sink.alerts)
+
+	// Next digest is sent one interval after the last
+	now = now.Add(30 * time.Minute)
+	err = engine.Process(context.Background(), testListing("4", "event4", "Wicked"))
+	require.NoError(t, err)
+	require.Len(t, sink.alerts, 1)
+
+	now = now.Add(30 * time.Minute)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1", "2", "3"}, {"4"}}, sink.alerts)
+}
+
+func TestEngineFailedAlertNotCooledDown(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:     "all",
+		Cooldown: Duration(time.Hour),
+	}, nil, &now)
+
+	sink.err = errors.New("sink unavailable")
+	err := engine.Process(context.Background(), testListing("1", "event1", "Coldplay"))
+	require.Error(t, err)
+
+	// A failed alert should not start the cooldown
+	sink.err = nil
+	now = now.Add(time.Minute)
+	err = engine.Process(context.Background(), testListing("1", "event1", "Coldplay"))
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}}, sink.alerts)
+
+	now = now.Add(time.Minute)
+	err = engine.Process(context.Background(), testListing("1", "event1", "Coldplay"))
+	require.NoError(t, err)
+	require.Len(t, sink.alerts, 1)
+}
+
+func TestEnginePartialSend(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	engine, sink := newTestEngine(t, Rule{
+		Name:           "all",
+		Digest:         true,
+		DigestInterval: Duration(time.Hour),
+	}, nil, &now)
+	otherSink := &recordingSink{}
+	engine.rules[0].sinks = append(engine.rules[0].sinks, otherSink)
+
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event2", "Hamilton"),
+	)
+	require.NoError(t, err)
+
+	// Listings not sent to a sink should only be retried to that sink
+	sink.failIds = map[string]bool{"2": true}
+	now = now.Add(time.Hour)
+	err = engine.Flush(context.Background())
+	require.Error(t, err)
+	require.Equal(t, [][]string{{"1"}}, sink.alerts)
+	require.Equal(t, [][]string{{"1", "2"}}, otherSink.alerts)
+
+	sink.failIds = nil
+	err = engine.Process(context.Background(), testListing("3", "event3", "Oasis"))
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"2", "3"}}, sink.alerts)
+	require.Equal(t, [][]string{{"1", "2"}, {"3"}}, otherSink.alerts)
+
+	// Alerts that fail for every sink should be kept pending
+	sink.err = errors.New("sink unavailable")
+	otherSink.err = errors.New("sink unavailable")
+	err = engine.Process(context.Background(), testListing("4", "event4", "Wicked"))
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	err = engine.Flush(context.Background())
+	require.Error(t, err)
+
+	sink.err = nil
+	otherSink.err = nil
+	now = now.Add(time.Hour)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"4"}, sink.alerts[2])
+	require.Equal(t, []string{"4"}, otherSink.alerts[2])
+}
+
+func TestEngineSendsUnlocked(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	blocking := &blockingSink{started: make(chan struct{}), unblocked: make(chan struct{})}
+	sink := &recordingSink{}
+	engine, err := NewEngine([]Rule{
+		{Name: "slow", Sink: blocking},
+		{Name: "fast", Sink: sink},
+	}, nil)
+	require.NoError(t, err)
+	engine.now = func() time.Time { return now }
+
+	processErr := make(chan error, 1)
+	go func() {
+		processErr <- engine.Process(context.Background(), testListing("1", "event1", "Coldplay"))
+	}()
+	<-blocking.started
+
+	// A slow sink should not block other rules, or other uses of the engine
+	err = engine.SaveState(filepath.Join(t.TempDir(), "rules-state.json"))
+	require.NoError(t, err)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+
+	close(blocking.unblocked)
+	require.NoError(t, <-processErr)
+	require.Equal(t, [][]string{{"1"}}, sink.alerts)
+}
+
+func TestEngineStatePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules-state.json")
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rule := Rule{
+		Name:             "all",
+		Cooldown:         Duration(time.Hour),
+		MaxAlertsPerHour: 1,
+	}
+
+	engine, sink := newTestEngine(t, rule, nil, &now)
+	err := engine.Process(
+		context.Background(),
+		testListing("1", "event1", "Coldplay"),
+		testListing("2", "event2", "Hamilton"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}}, sink.alerts)
+
+	err = engine.SaveState(path)
+	require.NoError(t, err)
+
+	// Cooldown and throttling should survive a restart
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	now = now.Add(10 * time.Minute)
+	engine, sink = newTestEngine(t, rule, state, &now)
+
+	err = engine.Process(
+		context.Background(),
+		testListing("3", "event1", "Coldplay"),
+		testListing("4", "event3", "Oasis"),
+	)
+	require.NoError(t, err)
+	require.Empty(t, sink.alerts)
+
+	now = now.Add(time.Hour)
+	err = engine.Flush(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"2", "4"}}, sink.alerts)
+}
+
+func TestNewEngineInvalidRules(t *testing.T) {
+	sink := &recordingSink{}
+
+	_, err := NewEngine([]Rule{{Sink: sink}}, nil)
+	require.Error(t, err)
+
+	_, err = NewEngine([]Rule{{Name: "rule"}}, nil)
+	require.Error(t, err)
+
+	_, err = NewEngine([]Rule{{Name: "rule", Sink: sink}, {Name: "rule", Sink: sink}}, nil)
+	require.Error(t, err)
+
+	_, err = NewEngine([]Rule{{Name: "rule", Sink: sink, QuietHours: &QuietHours{Start: "25:00", End: "07:00"}}}, nil)
+	require.Error(t, err)
+}
+
+func TestEngineSetRules(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rule := Rule{
+		Name:     "coldplay",
+		Cooldown: Duration(30 * time.Minute),
+	}
+	engine, sink := newTestEngine(t, rule, nil, &now)
+
+	err := engine.Process(context.Background(), testListing("1", "event1", "Coldplay"))
+	require.NoError(t, err)
+
+	// Rules that are kept should keep their cooldowns
+	rule.Sink = sink
+	newSink := &recordingSink{}
+	err = engine.SetRules([]Rule{rule, {Name: "hamilton", Filter: filter.Config{Event: "Hamilton"}, Sink: newSink}})
+	require.NoError(t, err)
+
+	err = engine.Process(
+		context.Background(),
+		testListing("2", "event1", "Coldplay"),
+		testListing("3", "event2", "Hamilton"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"1"}, {"3"}}, sink.alerts)
+	require.Equal(t, [][]string{{"3"}}, newSink.alerts)
+
+	// Invalid rules should not replace existing rules
+	err = engine.SetRules([]Rule{{Name: "rule"}})
+	require.Error(t, err)
+	err = engine.Process(context.Background(), testListing("4", "event3", "Hamilton"))
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"3"}, {"4"}}, newSink.alerts)
+}
@@ -0,0 +1,218 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+	"github.com/ahobsonsayers/twigots/notify"
+)
+
+// Default interval between digests
+const DefaultDigestInterval = time.Hour
+
+// CooldownScope is what a rule cooldown applies to.
+type CooldownScope string
+
+const (
+	// CooldownScopeEvent only alerts once per event per cooldown.
+	CooldownScopeEvent CooldownScope = "event"
+
+	// CooldownScopeListing only alerts once per listing per cooldown.
+	CooldownScopeListing CooldownScope = "listing"
+)
+
+// cooldownKey gets the key of a listing that a cooldown applies to.
+func (s CooldownScope) cooldownKey(listing twigots.TicketListing) string {
+	if s == CooldownScopeListing {
+		return "listing:" + listing.Id
+	}
+	return "event:" + listing.Event.Id
+}
+
+// Rule is an alert rule. Listings matching the rule filter are sent to the rule sinks,
+// subject to the rule cooldown, quiet hours and throttling.
+type Rule struct {
+	// Name of the rule. Required, and must be unique.
+	// It is used to identify the rule state, so changing it will reset the rule state.
+	Name string `json:"name"`
+
+	// Filter listings must match to be alerted about. Defaults to matching all listings.
+	Filter filter.Config `json:"filter"`
+
+	// Sinks to send alerts to.
+	Sinks []notify.SinkConfig `json:"sinks,omitempty"`
+
+	// Sink to send alerts to, as well as any configured sinks.
+	// Useful when creating rules in code. At least one sink must be set.
+	Sink notify.Sink `json:"-"`
+
+	// Cooldown after alerting about a listing, during which further listings with the same cooldown key
+	// (see CooldownScope) are dropped. Defaults to no cooldown.
+	Cooldown Duration `json:"cooldown,omitempty"`
+
+	// CooldownScope is what the cooldown applies to. Defaults to CooldownScopeEvent.
+	CooldownScope CooldownScope `json:"cooldownScope,omitempty"`
+
+	// QuietHours during which no alerts are sent. Listings matched during quiet hours
+	// are sent in a single alert once quiet hours end. Defaults to no quiet hours.
+	QuietHours *QuietHours `json:"quietHours,omitempty"`
+
+	// MaxAlertsPerHour is the maximum number of alerts sent in any hour. Listings matched once the maximum
+	// is reached are sent in a single alert once an alert can be sent again. Defaults to no maximum.
+	MaxAlertsPerHour int `json:"maxAlertsPerHour,omitempty"`
+
+	// Digest sends matched listings in a single alert every DigestInterval, rather than immediately.
+	Digest bool `json:"digest,omitempty"`
+
+	// DigestInterval is the interval between digests. Defaults to DefaultDigestInterval.
+	DigestInterval Duration `json:"digestInterval,omitempty"`
+}
+
+// compiledRule is a rule with its predicate, sink and quiet hours created.
+type compiledRule struct {
+	Rule
+	predicate  filter.TicketListingPredicate
+	sinks      []notify.Sink
+	quietHours *quietHours
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	if r.Name == "" {
+		return compiledRule{}, errors.New("name must be set")
+	}
+
+	switch r.CooldownScope {
+	case "":
+		r.CooldownScope = CooldownScopeEvent
+	case CooldownScopeEvent, CooldownScopeListing:
+	default:
+		return compiledRule{}, fmt.Errorf("cooldown scope '%s' is not valid", r.CooldownScope)
+	}
+
+	if r.MaxAlertsPerHour < 0 {
+		return compiledRule{}, errors.New("max alerts per hour must not be negative")
+	}
+
+	if r.DigestInterval <= 0 {
+		r.DigestInterval = Duration(DefaultDigestInterval)
+	}
+
+	sinks, err := notify.Sinks(r.Sinks...)
+	if err != nil {
+		return compiledRule{}, err
+	}
+	if r.Sink != nil {
+		sinks = append(sinks, r.Sink)
+	}
+	if len(sinks) == 0 {
+		return compiledRule{}, errors.New("at least one sink must be set")
+	}
+
+	var parsedQuietHours *quietHours
+	if r.QuietHours != nil {
+		parsedQuietHours, err = r.QuietHours.parse()
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("invalid quiet hours: %w", err)
+		}
+	}
+
+	return compiledRule{
+		Rule:       r,
+		predicate:  r.Filter.Predicate(),
+		sinks:      sinks,
+		quietHours: parsedQuietHours,
+	}, nil
+}
+
+// QuietHours is a daily period during which no alerts are sent.
+// If End is before Start, quiet hours span midnight e.g. 22:00 to 07:00.
+type QuietHours struct {
+	// Start time of quiet hours in 24-hour HH:MM format e.g. "22:00".
+	Start string `json:"start"`
+
+	// End time of quiet hours in 24-hour HH:MM format e.g. "07:00".
+	End string `json:"end"`
+
+	// TimeZone of the start and end times e.g. "Europe/London". Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type quietHours struct {
+	start    time.Duration // Since midnight
+	end      time.Duration // Since midnight
+	location *time.Location
+}
+
+func (q QuietHours) parse() (*quietHours, error) {
+	start, err := parseTimeOfDay(q.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+
+	end, err := parseTimeOfDay(q.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+
+	location := time.UTC
+	if q.TimeZone != "" {
+		location, err = time.LoadLocation(q.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+
+	return &quietHours{
+		start:    start,
+		end:      end,
+		location: location,
+	}, nil
+}
+
+// contains checks whether a time is within quiet hours.
+func (q *quietHours) contains(t time.Time) bool {
+	t = t.In(q.location)
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+
+	if q.start <= q.end {
+		return sinceMidnight >= q.start && sinceMidnight < q.end
+	}
+
+	// Quiet hours span midnight
+	return sinceMidnight >= q.start || sinceMidnight < q.end
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsedTime, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(parsedTime.Hour())*time.Hour + time.Duration(parsedTime.Minute())*time.Minute, nil
+}
+
+// Duration is a time.Duration that is marshalled to, and unmarshalled from, a string such as "1m30s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var durationString string
+	err := json.Unmarshal(data, &durationString)
+	if err != nil {
+		return err
+	}
+
+	parsedDuration, err := time.ParseDuration(durationString)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsedDuration)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
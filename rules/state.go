@@ -0,0 +1,115 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// State is the state of all rules. It should be persisted between runs
+// so that cooldowns and throttling survive restarts.
+type State struct {
+	// Rules is the state of each rule, keyed by rule name.
+	Rules map[string]*RuleState `json:"rules"`
+}
+
+// RuleState is the state of a rule.
+type RuleState struct {
+	// LastAlertedAt is the time each cooldown key was last alerted about.
+	LastAlertedAt map[string]time.Time `json:"lastAlertedAt,omitempty"`
+
+	// AlertedAt are the times of alerts sent in the last hour.
+	AlertedAt []time.Time `json:"alertedAt,omitempty"`
+
+	// Pending are the listings waiting to be sent, due to digests, quiet hours or throttling.
+	Pending []twigots.TicketListing `json:"pending,omitempty"`
+
+	// Unsent are listings of alerts that failed to send to some sinks, keyed by the index of the sink.
+	// They are only retried to that sink, along with the next alert of the rule.
+	Unsent map[int][]twigots.TicketListing `json:"unsent,omitempty"`
+
+	// LastDigestAt is the time the last digest was sent.
+	LastDigestAt time.Time `json:"lastDigestAt,omitempty"`
+}
+
+// NewState creates an empty state.
+func NewState() *State {
+	return &State{Rules: make(map[string]*RuleState)}
+}
+
+// LoadState loads state from a file. If the file does not exist, an empty state is returned.
+func LoadState(path string) (*State, error) {
+	stateJson, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules state: %w", err)
+	}
+
+	state := NewState()
+	err = json.Unmarshal(stateJson, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rules state: %w", err)
+	}
+	if state.Rules == nil {
+		state.Rules = make(map[string]*RuleState)
+	}
+
+	return state, nil
+}
+
+// Save the state to a file, writing to a temporary file first so state is never partially written.
+func (s *State) Save(path string) error {
+	stateJson, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rules state: %w", err)
+	}
+
+	temporaryPath := path + ".tmp"
+	err = os.WriteFile(temporaryPath, stateJson, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write rules state: %w", err)
+	}
+
+	err = os.Rename(temporaryPath, path)
+	if err != nil {
+		return fmt.Errorf("failed to write rules state: %w", err)
+	}
+
+	return nil
+}
+
+// rule gets the state of a rule, creating it if it does not exist.
+func (s *State) rule(name string) *RuleState {
+	ruleState, ok := s.Rules[name]
+	if !ok {
+		ruleState = &RuleState{}
+		s.Rules[name] = ruleState
+	}
+	if ruleState.LastAlertedAt == nil {
+		ruleState.LastAlertedAt = make(map[string]time.Time)
+	}
+	return ruleState
+}
+
+// prune removes expired cooldowns and alerts older than an hour.
+func (s *RuleState) prune(now time.Time, cooldown time.Duration) {
+	for key, lastAlertedAt := range s.LastAlertedAt {
+		if now.Sub(lastAlertedAt) >= cooldown {
+			delete(s.LastAlertedAt, key)
+		}
+	}
+
+	alertedAt := s.AlertedAt[:0]
+	for _, t := range s.AlertedAt {
+		if now.Sub(t) < time.Hour {
+			alertedAt = append(alertedAt, t)
+		}
+	}
+	s.AlertedAt = alertedAt
+}
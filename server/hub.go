@@ -0,0 +1,168 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+)
+
+const (
+	// Maximum number of recent events kept to replay to clients resuming a stream
+	defaultHubBufferSize = 1000
+
+	// Maximum number of events queued for a subscriber. If a subscriber falls further behind
+	// than this, it is disconnected, and should resume using the id of the last event it received.
+	subscriberQueueSize = 256
+)
+
+// StreamEventType is the type of a stream event.
+type StreamEventType string
+
+const (
+	// StreamEventTypeListing is sent when a new ticket listing is seen.
+	StreamEventTypeListing StreamEventType = "listing"
+
+	// StreamEventTypeDelist is sent when a ticket listing is delisted.
+	StreamEventTypeDelist StreamEventType = "delist"
+)
+
+// StreamEvent is an event sent to clients of the listing stream.
+type StreamEvent struct {
+	// Sequence number of the event. This is used as the event id, and increases with every event.
+	// Sequence numbers start from the time the server started (in microseconds since the Unix epoch),
+	// so they continue to increase across server restarts.
+	Sequence uint64 `json:"sequence"`
+
+	Type StreamEventType `json:"type"`
+
+	// At is the time the listing was seen or delisted.
+	At time.Time `json:"at"`
+
+	ListingId string `json:"listingId"`
+
+	// Listing is the new ticket listing. For delist events, this is the last seen version
+	// of the listing, or nil if it was never seen.
+	Listing *twigots.TicketListing `json:"listing,omitempty"`
+}
+
+// createdAt gets the time used to resume streams from a time.
+// This is the listing creation time if known, otherwise the time of the event.
+func (e StreamEvent) createdAt() time.Time {
+	if e.Type == StreamEventTypeListing && e.Listing != nil {
+		return e.Listing.CreatedAt.Time
+	}
+	return e.At
+}
+
+// resumePoint is the point a client resumes a stream from.
+// If both are nil, no past events are replayed.
+type resumePoint struct {
+	afterSequence  *uint64
+	afterCreatedAt *time.Time
+}
+
+func (p resumePoint) includes(event StreamEvent) bool {
+	if p.afterSequence != nil {
+		return event.Sequence > *p.afterSequence
+	}
+	if p.afterCreatedAt != nil {
+		return event.createdAt().After(*p.afterCreatedAt)
+	}
+	return false
+}
+
+// subscriber is a client subscribed to stream events.
+type subscriber struct {
+	events    chan StreamEvent
+	predicate filter.TicketListingPredicate
+}
+
+// matches checks whether an event matches the subscriber filter.
+// Delist events of listings that were never seen always match, as they cannot be filtered.
+func (s *subscriber) matches(event StreamEvent) bool {
+	return event.Listing == nil || s.predicate(*event.Listing)
+}
+
+// hub publishes stream events to subscribers, keeping a buffer of recent events so clients can resume streams.
+type hub struct {
+	bufferSize int
+
+	mutex       sync.Mutex
+	sequence    uint64
+	buffer      []StreamEvent
+	subscribers map[*subscriber]struct{}
+}
+
+func newHub(bufferSize int) *hub {
+	return &hub{
+		bufferSize:  bufferSize,
+		sequence:    uint64(time.Now().UnixMicro()),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// publish events to all subscribers, assigning each event a sequence number.
+// Subscribers that have fallen too far behind are disconnected.
+func (h *hub) publish(events ...StreamEvent) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, event := range events {
+		h.sequence++
+		event.Sequence = h.sequence
+
+		h.buffer = append(h.buffer, event)
+		if len(h.buffer) > h.bufferSize {
+			h.buffer = h.buffer[len(h.buffer)-h.bufferSize:]
+		}
+
+		for subscriber := range h.subscribers {
+			if !subscriber.matches(event) {
+				continue
+			}
+
+			select {
+			case subscriber.events <- event:
+			default:
+				// Subscriber is too far behind
+				delete(h.subscribers, subscriber)
+				close(subscriber.events)
+			}
+		}
+	}
+}
+
+// subscribe to events matching a predicate. Buffered events after the resume point are returned,
+// and should be sent before any events received from the subscriber.
+func (h *hub) subscribe(predicate filter.TicketListingPredicate, resume resumePoint) (*subscriber, []StreamEvent) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	subscriber := &subscriber{
+		events:    make(chan StreamEvent, subscriberQueueSize),
+		predicate: predicate,
+	}
+	h.subscribers[subscriber] = struct{}{}
+
+	var backlog []StreamEvent
+	for _, event := range h.buffer {
+		if resume.includes(event) && subscriber.matches(event) {
+			backlog = append(backlog, event)
+		}
+	}
+
+	return subscriber, backlog
+}
+
+// unsubscribe a subscriber, if it has not already been disconnected.
+func (h *hub) unsubscribe(subscriber *subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.subscribers[subscriber]; ok {
+		delete(h.subscribers, subscriber)
+		close(subscriber.events)
+	}
+}
@@ -0,0 +1,568 @@
+// Package server provides an HTTP API exposing ticket listings, events and saved watches as JSON.
+//
+// See openapi.json (served at GET /openapi.json) for the API specification.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+	"github.com/ahobsonsayers/twigots/notify"
+	"github.com/ahobsonsayers/twigots/rules"
+	"github.com/ahobsonsayers/twigots/store"
+	"github.com/ahobsonsayers/twigots/watch"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+const (
+	// maxListingsPerRequest is the maximum number of listings that can be fetched by a single GET /listings.
+	maxListingsPerRequest = 1000
+
+	// maxRequestBodySize is the maximum size of a request body in bytes.
+	maxRequestBodySize = 1 << 20
+)
+
+// watchSinkTypes are the types of sinks watches created through the API can send alerts to.
+// Sinks that run commands or write files on the host are not allowed, as watches are created remotely.
+var watchSinkTypes = map[notify.SinkType]bool{
+	notify.SinkTypeWebhook: true,
+	notify.SinkTypeSlack:   true,
+	notify.SinkTypeDiscord: true,
+	notify.SinkTypeNtfy:    true,
+	notify.SinkTypeGotify:  true,
+}
+
+// Server is an HTTP API server exposing ticket listings, events and saved watches.
+//
+// Listings are fetched live by GET /listings. Listings fetched, or processed using Process,
+// are saved to the store, which is used to serve GET /listings/{id} and GET /events.
+//
+// Listings processed using Process, and delists recorded using Delist, are streamed live to
+// clients of GET /stream (Server-Sent Events) and GET /stream/ws (WebSocket).
+type Server struct {
+	fetcher watch.Fetcher
+	store   store.Store
+	watches *Watches
+	hub     *hub
+
+	mux   *http.ServeMux
+	token string
+
+	engineMutex  sync.Mutex
+	engine       *rules.Engine
+	rulesChanged bool
+	rulesState   *rules.State
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// Option is an option used to configure a server.
+type Option func(*Server)
+
+// WithToken requires requests to the watch endpoints to be authenticated with the token,
+// using an `Authorization: Bearer <token>` header.
+// Watches can send alerts to any url, so watch endpoints are disabled unless a token is set.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// New creates a server using the specified fetcher to fetch listings, store to save listings,
+// and set of saved watches. rulesState is the state of the watch rules, and can be nil.
+func New(
+	fetcher watch.Fetcher,
+	listingStore store.Store,
+	watches *Watches,
+	rulesState *rules.State,
+	options ...Option,
+) *Server {
+	if rulesState == nil {
+		rulesState = rules.NewState()
+	}
+
+	server := &Server{
+		fetcher:    fetcher,
+		store:      listingStore,
+		watches:    watches,
+		hub:        newHub(defaultHubBufferSize),
+		rulesState: rulesState,
+	}
+	for _, option := range options {
+		option(server)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", server.handleOpenAPI)
+	mux.HandleFunc("GET /listings", server.handleListListings)
+	mux.HandleFunc("GET /listings/{id}", server.handleGetListing)
+	mux.HandleFunc("GET /events", server.handleListEvents)
+	mux.HandleFunc("GET /stream", server.handleStream)
+	mux.HandleFunc("GET /stream/ws", server.handleWebSocketStream)
+	mux.HandleFunc("GET /watches", server.requireToken(server.handleListWatches))
+	mux.HandleFunc("POST /watches", server.requireToken(server.handleCreateWatch))
+	mux.HandleFunc("GET /watches/{id}", server.requireToken(server.handleGetWatch))
+	mux.HandleFunc("PUT /watches/{id}", server.requireToken(server.handleUpdateWatch))
+	mux.HandleFunc("DELETE /watches/{id}", server.requireToken(server.handleDeleteWatch))
+	server.mux = mux
+
+	return server
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Process saves new ticket listings to the store, streams them to clients, and evaluates saved watches against them.
+// This should be called with new listings found by a poller.
+func (s *Server) Process(ctx context.Context, seenAt time.Time, listings ...twigots.TicketListing) error {
+	err := s.store.Upsert(ctx, seenAt, listings...)
+	if err != nil {
+		return fmt.Errorf("failed to save listings: %w", err)
+	}
+	s.publishListings(seenAt, listings)
+
+	engine, err := s.rulesEngine()
+	if err != nil {
+		return err
+	}
+
+	return engine.Process(ctx, listings...)
+}
+
+// Flush sends pending alerts of saved watches. See rules.Engine.Flush.
+func (s *Server) Flush(ctx context.Context) error {
+	engine, err := s.rulesEngine()
+	if err != nil {
+		return err
+	}
+	return engine.Flush(ctx)
+}
+
+// SaveRulesState saves the state of the watch rules to a file. See rules.State.Save.
+func (s *Server) SaveRulesState(path string) error {
+	engine, err := s.rulesEngine()
+	if err != nil {
+		return err
+	}
+	return engine.SaveState(path)
+}
+
+// rulesEngine gets the engine evaluating the rules of saved watches, updating its rules if watches have changed.
+// Rules are named by watch id, so rule state is kept when rules are updated.
+func (s *Server) rulesEngine() (*rules.Engine, error) {
+	s.engineMutex.Lock()
+	defer s.engineMutex.Unlock()
+
+	if s.engine != nil && !s.rulesChanged {
+		return s.engine, nil
+	}
+
+	watches := s.watches.List()
+	watchRules := make([]rules.Rule, 0, len(watches))
+	for _, watch := range watches {
+		rule := watch.Rule
+		rule.Name = watch.Id
+		watchRules = append(watchRules, rule)
+	}
+
+	if s.engine == nil {
+		engine, err := rules.NewEngine(watchRules, s.rulesState)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create watch rules: %w", err)
+		}
+		s.engine = engine
+	} else {
+		err := s.engine.SetRules(watchRules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update watch rules: %w", err)
+		}
+	}
+
+	s.rulesChanged = false
+	return s.engine, nil
+}
+
+// resetRules causes the rules of the rules engine to be updated the next time it is used.
+func (s *Server) resetRules() {
+	s.engineMutex.Lock()
+	defer s.engineMutex.Unlock()
+	s.rulesChanged = true
+}
+
+// requireToken wraps a handler, only calling it if the request is authenticated with the server token.
+// See WithToken.
+func (s *Server) requireToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" {
+			writeError(w, http.StatusForbidden, errors.New("watches are disabled as no token is set"))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
+
+// listingsResponse is the response of GET /listings.
+type listingsResponse struct {
+	Listings twigots.TicketListings `json:"listings"`
+}
+
+func (s *Server) handleListListings(w http.ResponseWriter, r *http.Request) {
+	input, predicate, err := parseListingsQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	listings, err := s.fetcher.FetchTicketListings(r.Context(), input)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch listings: %w", err))
+		return
+	}
+
+	err = s.store.Upsert(r.Context(), time.Now(), listings...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to save listings: %w", err))
+		return
+	}
+
+	listings = filter.FilterTicketListings(listings, predicate)
+	if listings == nil {
+		listings = twigots.TicketListings{}
+	}
+
+	writeJSON(w, http.StatusOK, listingsResponse{Listings: listings})
+}
+
+// parseListingsQuery parses the query parameters of GET /listings into a fetch input and filter predicate.
+func parseListingsQuery(r *http.Request) (twigots.FetchTicketListingsInput, filter.TicketListingPredicate, error) {
+	query := r.URL.Query()
+
+	input := twigots.FetchTicketListingsInput{
+		Country: twigots.CountryUnitedKingdom,
+	}
+
+	if countryString := query.Get("country"); countryString != "" {
+		err := input.Country.UnmarshalText([]byte(countryString))
+		if err != nil {
+			return twigots.FetchTicketListingsInput{}, nil, err
+		}
+	}
+
+	var err error
+	input.Regions, err = parseRegionsQuery(query)
+	if err != nil {
+		return twigots.FetchTicketListingsInput{}, nil, err
+	}
+	input.MaxNumber, err = parseIntParam(query.Get("maxNumber"), "maxNumber")
+	if err != nil {
+		return twigots.FetchTicketListingsInput{}, nil, err
+	}
+	input.CreatedAfter, err = parseTimeParam(query.Get("createdAfter"), "createdAfter")
+	if err != nil {
+		return twigots.FetchTicketListingsInput{}, nil, err
+	}
+	input.CreatedBefore, err = parseTimeParam(query.Get("createdBefore"), "createdBefore")
+	if err != nil {
+		return twigots.FetchTicketListingsInput{}, nil, err
+	}
+
+	if input.MaxNumber < 0 || input.MaxNumber > maxListingsPerRequest {
+		return twigots.FetchTicketListingsInput{}, nil, fmt.Errorf(
+			"maxNumber must be between 0 and %d", maxListingsPerRequest,
+		)
+	}
+	if !input.CreatedBefore.IsZero() && input.CreatedBefore.Before(input.CreatedAfter) {
+		return twigots.FetchTicketListingsInput{}, nil, errors.New("createdBefore must be after createdAfter")
+	}
+
+	filterConfig, err := parseFilterQuery(query)
+	if err != nil {
+		return twigots.FetchTicketListingsInput{}, nil, err
+	}
+
+	return input, filterConfig.Predicate(), nil
+}
+
+// parseRegionsQuery parses the (repeatable) region query parameter.
+func parseRegionsQuery(query url.Values) ([]twigots.Region, error) {
+	var regions []twigots.Region
+	for _, regionString := range query["region"] {
+		var region twigots.Region
+		err := region.UnmarshalText([]byte(regionString))
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// parseFilterQuery parses filter query parameters into a filter config.
+// Regions are not parsed, as they are usually used when fetching listings.
+func parseFilterQuery(query url.Values) (filter.Config, error) {
+	filterConfig := filter.Config{
+		Event: query.Get("event"),
+	}
+
+	var err error
+	filterConfig.EventSimilarity, err = parseFloatParam(query.Get("eventSimilarity"), "eventSimilarity")
+	if err != nil {
+		return filter.Config{}, err
+	}
+	filterConfig.NumTickets, err = parseIntParam(query.Get("numTickets"), "numTickets")
+	if err != nil {
+		return filter.Config{}, err
+	}
+	if maxTicketPrice := query.Get("maxTicketPrice"); maxTicketPrice != "" {
+		filterConfig.MaxTicketPrice, err = filter.ParseMoney(maxTicketPrice)
+		if err != nil {
+			return filter.Config{}, fmt.Errorf("invalid maxTicketPrice: %w", err)
+		}
+	}
+	filterConfig.MinDiscount, err = parseFloatParam(query.Get("minDiscount"), "minDiscount")
+	if err != nil {
+		return filter.Config{}, err
+	}
+
+	return filterConfig, nil
+}
+
+func (s *Server) handleGetListing(w http.ResponseWriter, r *http.Request) {
+	record, err := s.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if record == nil {
+		writeError(w, http.StatusNotFound, errors.New("listing not found"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+// eventSummary is an event, and a summary of its stored listings.
+type eventSummary struct {
+	Event                 twigots.Event `json:"event"`
+	NumListings           int           `json:"numListings"`
+	NumTickets            int           `json:"numTickets"`
+	MinTicketPriceInclFee twigots.Price `json:"minTicketPriceInclFee"`
+}
+
+// eventsResponse is the response of GET /events.
+type eventsResponse struct {
+	Events []eventSummary `json:"events"`
+}
+
+func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	query := store.Query{
+		TourId: r.URL.Query().Get("tourId"),
+	}
+	if regionString := r.URL.Query().Get("region"); regionString != "" {
+		err := query.Region.UnmarshalText([]byte(regionString))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	records, err := s.store.Query(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	listings := make(twigots.TicketListings, 0, len(records))
+	for _, record := range records {
+		listings = append(listings, record.Listing)
+	}
+
+	eventListings := listings.GroupByEvent()
+	events := make([]eventSummary, 0, len(eventListings))
+	for _, event := range listings.SortBy(twigots.CompareByEventDate).Events() {
+		summary := eventListings[event.Id].Summary()
+		events = append(events, eventSummary{
+			Event:                 event,
+			NumListings:           summary.NumListings,
+			NumTickets:            summary.NumTickets,
+			MinTicketPriceInclFee: summary.MinTicketPriceInclFee,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, eventsResponse{Events: events})
+}
+
+// watchesResponse is the response of GET /watches.
+type watchesResponse struct {
+	Watches []Watch `json:"watches"`
+}
+
+func (s *Server) handleListWatches(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, watchesResponse{Watches: s.watches.List()})
+}
+
+func (s *Server) handleCreateWatch(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeRule(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	watch, err := s.watches.Create(rule)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.resetRules()
+
+	writeJSON(w, http.StatusCreated, watch)
+}
+
+func (s *Server) handleGetWatch(w http.ResponseWriter, r *http.Request) {
+	watch := s.watches.Get(r.PathValue("id"))
+	if watch == nil {
+		writeError(w, http.StatusNotFound, ErrWatchNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, watch)
+}
+
+func (s *Server) handleUpdateWatch(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeRule(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	watch, err := s.watches.Update(r.PathValue("id"), rule)
+	if errors.Is(err, ErrWatchNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.resetRules()
+
+	writeJSON(w, http.StatusOK, watch)
+}
+
+func (s *Server) handleDeleteWatch(w http.ResponseWriter, r *http.Request) {
+	err := s.watches.Delete(r.PathValue("id"))
+	if errors.Is(err, ErrWatchNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.resetRules()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRule decodes and validates the watch rule in a request body.
+// Only sinks in watchSinkTypes are allowed.
+func decodeRule(w http.ResponseWriter, r *http.Request) (rules.Rule, error) {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	var rule rules.Rule
+	err := decoder.Decode(&rule)
+	if err != nil {
+		return rules.Rule{}, fmt.Errorf("invalid watch: %w", err)
+	}
+
+	for _, sinkConfig := range rule.Sinks {
+		if !watchSinkTypes[sinkConfig.Type] {
+			return rules.Rule{}, fmt.Errorf("invalid watch: sink type '%s' is not allowed", sinkConfig.Type)
+		}
+	}
+
+	// Validate the rule by creating an engine. Sinks are validated, but not used.
+	_, err = rules.NewEngine([]rules.Rule{rule}, nil)
+	if err != nil {
+		return rules.Rule{}, fmt.Errorf("invalid watch: %w", err)
+	}
+
+	return rule, nil
+}
+
+// errorResponse is the response of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	writeJSON(w, statusCode, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func parseIntParam(value, name string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsedValue, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return parsedValue, nil
+}
+
+func parseFloatParam(value, name string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsedValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return parsedValue, nil
+}
+
+func parseTimeParam(value, name string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsedValue, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return parsedValue, nil
+}
@@ -0,0 +1,327 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/rules"
+	"github.com/ahobsonsayers/twigots/store"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetcher returns its listings, recording the inputs it was called with.
+type fakeFetcher struct {
+	listings twigots.TicketListings
+	inputs   []twigots.FetchTicketListingsInput
+}
+
+func (f *fakeFetcher) FetchTicketListings(
+	_ context.Context,
+	input twigots.FetchTicketListingsInput,
+) (twigots.TicketListings, error) {
+	f.inputs = append(f.inputs, input)
+	return f.listings, nil
+}
+
+const testToken = "token"
+
+func newTestServer(t *testing.T, listings ...twigots.TicketListing) (*Server, *fakeFetcher) {
+	listingStore, err := store.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listingStore.Close() })
+
+	watches, err := NewWatches(filepath.Join(t.TempDir(), "watches.json"))
+	require.NoError(t, err)
+
+	fetcher := &fakeFetcher{listings: listings}
+	return New(fetcher, listingStore, watches, nil, WithToken(testToken)), fetcher
+}
+
+func testListing(id, eventId, eventName string, numTickets int, date string) twigots.TicketListing {
+	var eventDate twigots.Date
+	_ = json.Unmarshal([]byte(`"`+date+`"`), &eventDate)
+
+	return twigots.TicketListing{
+		Id:         id,
+		CreatedAt:  twigots.UnixTime{Time: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		NumTickets: numTickets,
+		TotalPriceExclFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(numTickets) * 5000,
+		},
+		TwicketsFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(numTickets) * 500,
+		},
+		OriginalTotalPrice: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(numTickets) * 5000,
+		},
+		Event: twigots.Event{
+			Id:   eventId,
+			Name: eventName,
+			Date: eventDate,
+		},
+	}
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	var requestBody bytes.Buffer
+	if body != nil {
+		err := json.NewEncoder(&requestBody).Encode(body)
+		require.NoError(t, err)
+	}
+
+	request := httptest.NewRequest(method, path, &requestBody)
+	request.Header.Set("Authorization", "Bearer "+testToken)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decodeResponse[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	var value T
+	err := json.Unmarshal(recorder.Body.Bytes(), &value)
+	require.NoError(t, err, recorder.Body.String())
+	return value
+}
+
+func TestListListings(t *testing.T) {
+	server, fetcher := newTestServer(
+		t,
+		testListing("1", "event1", "Coldplay", 2, "2024-07-01"),
+		testListing("2", "event2", "Hamilton", 1, "2024-08-01"),
+	)
+
+	recorder := doRequest(
+		t, server, http.MethodGet,
+		"/listings?region=GBLO&region=GBNW&maxNumber=50&createdAfter=2024-06-01T00:00:00Z&event=Coldplay&numTickets=2",
+		nil,
+	)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	response := decodeResponse[listingsResponse](t, recorder)
+	require.Len(t, response.Listings, 1)
+	require.Equal(t, "1", response.Listings[0].Id)
+
+	require.Len(t, fetcher.inputs, 1)
+	input := fetcher.inputs[0]
+	require.Equal(t, twigots.CountryUnitedKingdom, input.Country)
+	require.Equal(t, []twigots.Region{twigots.RegionLondon, twigots.RegionNorthWest}, input.Regions)
+	require.Equal(t, 50, input.MaxNumber)
+	require.Equal(t, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), input.CreatedAfter)
+
+	// Fetched listings should be saved, even if they do not match the filters
+	recorder = doRequest(t, server, http.MethodGet, "/listings/2", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	record := decodeResponse[store.Record](t, recorder)
+	require.Equal(t, "Hamilton", record.Listing.Event.Name)
+}
+
+func TestListListingsInvalidQuery(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	for _, query := range []string{
+		"country=XX",
+		"region=XX",
+		"maxNumber=ten",
+		"maxNumber=-1",
+		"maxNumber=1001",
+		"createdAfter=yesterday",
+		"createdAfter=2024-06-02T00:00:00Z&createdBefore=2024-06-01T00:00:00Z",
+		"maxTicketPrice=cheap",
+	} {
+		recorder := doRequest(t, server, http.MethodGet, "/listings?"+query, nil)
+		require.Equal(t, http.StatusBadRequest, recorder.Code, query)
+		require.NotEmpty(t, decodeResponse[errorResponse](t, recorder).Error)
+	}
+}
+
+func TestGetListingNotFound(t *testing.T) {
+	server, _ := newTestServer(t)
+	recorder := doRequest(t, server, http.MethodGet, "/listings/1", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestListEvents(t *testing.T) {
+	server, _ := newTestServer(t)
+	err := server.Process(
+		context.Background(),
+		time.Now(),
+		testListing("1", "event2", "Hamilton", 1, "2024-08-01"),
+		testListing("2", "event1", "Coldplay", 2, "2024-07-01"),
+		testListing("3", "event1", "Coldplay", 4, "2024-07-01"),
+	)
+	require.NoError(t, err)
+
+	recorder := doRequest(t, server, http.MethodGet, "/events", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	response := decodeResponse[eventsResponse](t, recorder)
+	require.Len(t, response.Events, 2)
+	require.Equal(t, "Coldplay", response.Events[0].Event.Name)
+	require.Equal(t, 2, response.Events[0].NumListings)
+	require.Equal(t, 6, response.Events[0].NumTickets)
+	require.Equal(t, int64(5500), response.Events[0].MinTicketPriceInclFee.Amount)
+	require.Equal(t, "Hamilton", response.Events[1].Event.Name)
+}
+
+func TestWatches(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	var numAlerts atomic.Int32
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		numAlerts.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(webhookServer.Close)
+
+	// Create
+	recorder := doRequest(t, server, http.MethodPost, "/watches", map[string]any{
+		"name":   "Coldplay",
+		"filter": map[string]any{"event": "Coldplay"},
+		"sinks":  []map[string]any{{"type": "webhook", "url": webhookServer.URL}},
+	})
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	watch := decodeResponse[Watch](t, recorder)
+	require.NotEmpty(t, watch.Id)
+	require.Equal(t, "Coldplay", watch.Name)
+
+	// Get
+	recorder = doRequest(t, server, http.MethodGet, "/watches/"+watch.Id, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Coldplay", decodeResponse[Watch](t, recorder).Filter.Event)
+
+	// Update
+	recorder = doRequest(t, server, http.MethodPut, "/watches/"+watch.Id, map[string]any{
+		"name":     "Coldplay pairs",
+		"filter":   map[string]any{"event": "Coldplay", "numTickets": 2},
+		"sinks":    []map[string]any{{"type": "webhook", "url": webhookServer.URL}},
+		"cooldown": "1h",
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	updatedWatch := decodeResponse[Watch](t, recorder)
+	require.Equal(t, "Coldplay pairs", updatedWatch.Name)
+	require.Equal(t, rules.Duration(time.Hour), updatedWatch.Cooldown)
+
+	// List
+	recorder = doRequest(t, server, http.MethodGet, "/watches", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, decodeResponse[watchesResponse](t, recorder).Watches, 1)
+
+	// Watches should be evaluated against processed listings
+	err := server.Process(
+		context.Background(),
+		time.Now(),
+		testListing("1", "event1", "Coldplay", 2, "2024-07-01"),
+		testListing("2", "event1", "Coldplay", 2, "2024-07-01"),
+		testListing("3", "event1", "Coldplay", 4, "2024-07-01"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), numAlerts.Load())
+
+	// Delete
+	recorder = doRequest(t, server, http.MethodDelete, "/watches/"+watch.Id, nil)
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	recorder = doRequest(t, server, http.MethodGet, "/watches/"+watch.Id, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder = doRequest(t, server, http.MethodDelete, "/watches/"+watch.Id, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestCreateWatchInvalid(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	// No sinks
+	recorder := doRequest(t, server, http.MethodPost, "/watches", map[string]any{"name": "Coldplay"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Unknown field
+	recorder = doRequest(t, server, http.MethodPost, "/watches", map[string]any{
+		"name":  "Coldplay",
+		"sinks": []map[string]any{{"type": "webhook", "url": "http://localhost"}},
+		"foo":   "bar",
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Sinks that run commands or write files on the host are not allowed
+	for _, sink := range []map[string]any{
+		{"type": "exec", "command": []string{"touch", "hacked"}},
+		{"type": "file", "path": "hacked"},
+		{"type": "stdout"},
+	} {
+		recorder = doRequest(t, server, http.MethodPost, "/watches", map[string]any{
+			"name":  "Coldplay",
+			"sinks": []map[string]any{sink},
+		})
+		require.Equal(t, http.StatusBadRequest, recorder.Code, sink["type"])
+		require.Contains(t, decodeResponse[errorResponse](t, recorder).Error, "not allowed")
+	}
+
+	// Body too large
+	recorder = doRequest(t, server, http.MethodPost, "/watches", map[string]any{
+		"name":  strings.Repeat("a", maxRequestBodySize),
+		"sinks": []map[string]any{{"type": "webhook", "url": "http://localhost"}},
+	})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestWatchesToken(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	// Requests without the token should be rejected
+	for _, authorization := range []string{"", "Bearer wrong", testToken} {
+		request := httptest.NewRequest(http.MethodGet, "/watches", http.NoBody)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code, authorization)
+	}
+
+	recorder := doRequest(t, server, http.MethodGet, "/watches", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Watches should be disabled if no token is set
+	watches, err := NewWatches(filepath.Join(t.TempDir(), "watches.json"))
+	require.NoError(t, err)
+	server = New(&fakeFetcher{}, nil, watches, nil)
+	recorder = doRequest(t, server, http.MethodPost, "/watches", map[string]any{
+		"name":  "Coldplay",
+		"sinks": []map[string]any{{"type": "webhook", "url": "http://localhost"}},
+	})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Empty(t, watches.List())
+}
+
+func TestWatchesPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watches.json")
+	watches, err := NewWatches(path)
+	require.NoError(t, err)
+
+	watch, err := watches.Create(rules.Rule{Name: "Coldplay"})
+	require.NoError(t, err)
+
+	watches, err = NewWatches(path)
+	require.NoError(t, err)
+	require.Equal(t, []Watch{watch}, watches.List())
+}
+
+func TestOpenAPI(t *testing.T) {
+	server, _ := newTestServer(t)
+	recorder := doRequest(t, server, http.MethodGet, "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	document := decodeResponse[map[string]any](t, recorder)
+	require.Equal(t, "3.0.3", document["openapi"])
+}
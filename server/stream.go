@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/filter"
+	"golang.org/x/net/websocket"
+)
+
+// Interval between keepalive messages sent to idle stream clients
+var streamKeepaliveInterval = 30 * time.Second
+
+// Delist records that the ticket listing with the specified id was delisted, and notifies stream clients.
+func (s *Server) Delist(ctx context.Context, id string, delistedAt time.Time) error {
+	record, err := s.store.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get listing: %w", err)
+	}
+
+	err = s.store.Delist(ctx, id, delistedAt)
+	if err != nil {
+		return fmt.Errorf("failed to delist listing: %w", err)
+	}
+
+	event := StreamEvent{
+		Type:      StreamEventTypeDelist,
+		At:        delistedAt,
+		ListingId: id,
+	}
+	if record != nil {
+		event.Listing = &record.Listing
+	}
+	s.hub.publish(event)
+
+	return nil
+}
+
+// publishListings notifies stream clients of new ticket listings.
+func (s *Server) publishListings(seenAt time.Time, listings []twigots.TicketListing) {
+	events := make([]StreamEvent, 0, len(listings))
+	for _, listing := range listings {
+		events = append(events, StreamEvent{
+			Type:      StreamEventTypeListing,
+			At:        seenAt,
+			ListingId: listing.Id,
+			Listing:   &listing,
+		})
+	}
+	s.hub.publish(events...)
+}
+
+// handleStream streams events to a client using Server-Sent Events.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+
+	predicate, resume, err := parseStreamQuery(r.URL.Query(), lastEventId)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	subscriber, backlog := s.hub.subscribe(predicate, resume)
+	defer s.hub.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for _, event := range backlog {
+		err := writeServerSentEvent(w, event)
+		if err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(streamKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			_, err := fmt.Fprint(w, ": keepalive\n\n")
+			if err != nil {
+				return
+			}
+		case event, ok := <-subscriber.events:
+			if !ok {
+				// Disconnected for falling behind. Client should reconnect using Last-Event-ID.
+				return
+			}
+			err := writeServerSentEvent(w, event)
+			if err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event StreamEvent) error {
+	eventJson, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, eventJson)
+	return err
+}
+
+// handleWebSocketStream streams events to a client using a WebSocket. Each message is a JSON stream event.
+// As browsers cannot set headers on WebSocket requests, streams are resumed using the lastEventId query parameter.
+func (s *Server) handleWebSocketStream(w http.ResponseWriter, r *http.Request) {
+	predicate, resume, err := parseStreamQuery(r.URL.Query(), r.URL.Query().Get("lastEventId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	websocket.Handler(func(conn *websocket.Conn) {
+		subscriber, backlog := s.hub.subscribe(predicate, resume)
+		defer s.hub.unsubscribe(subscriber)
+
+		// Read (and discard) messages from the client to detect it closing the connection
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var message []byte
+			for websocket.Message.Receive(conn, &message) == nil {
+			}
+		}()
+
+		for _, event := range backlog {
+			err := websocket.JSON.Send(conn, event)
+			if err != nil {
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-r.Context().Done():
+				return
+			case event, ok := <-subscriber.events:
+				if !ok {
+					return
+				}
+				err := websocket.JSON.Send(conn, event)
+				if err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(w, r)
+}
+
+// parseStreamQuery parses the filters in the query parameters of a stream request,
+// and the point the client is resuming from.
+//
+// lastEventId can either be the sequence number of the last event received, or an RFC 3339 time,
+// in which case events of listings created after the time are replayed.
+func parseStreamQuery(query url.Values, lastEventId string) (filter.TicketListingPredicate, resumePoint, error) {
+	filterConfig, err := parseFilterQuery(query)
+	if err != nil {
+		return nil, resumePoint{}, err
+	}
+	filterConfig.Regions, err = parseRegionsQuery(query)
+	if err != nil {
+		return nil, resumePoint{}, err
+	}
+
+	var resume resumePoint
+	if lastEventId != "" {
+		sequence, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err == nil {
+			resume.afterSequence = &sequence
+		} else {
+			createdAt, err := time.Parse(time.RFC3339, lastEventId)
+			if err != nil {
+				return nil, resumePoint{}, errors.New("last event id must be a sequence number or an RFC 3339 time")
+			}
+			resume.afterCreatedAt = &createdAt
+		}
+	}
+
+	return filterConfig.Predicate(), resume, nil
+}
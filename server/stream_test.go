@@ -0,0 +1,200 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// sseReader reads Server-Sent Events from a response.
+type sseReader struct {
+	scanner *bufio.Scanner
+}
+
+// next reads the next event, skipping comments.
+func (r *sseReader) next(t *testing.T) (id, eventType string, event StreamEvent) {
+	var data string
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		switch {
+		case line == "" && data != "":
+			err := json.Unmarshal([]byte(data), &event)
+			require.NoError(t, err)
+			return id, eventType, event
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	require.NoError(t, r.scanner.Err())
+	t.Fatal("stream ended")
+	return "", "", StreamEvent{}
+}
+
+func openStream(t *testing.T, url, lastEventId string) *sseReader {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	require.NoError(t, err)
+	if lastEventId != "" {
+		request.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = response.Body.Close() })
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	return &sseReader{scanner: bufio.NewScanner(response.Body)}
+}
+
+func TestStream(t *testing.T) {
+	server, _ := newTestServer(t)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	stream := openStream(t, httpServer.URL+"/stream?event=Coldplay", "")
+
+	err := server.Process(
+		context.Background(),
+		time.Now(),
+		testListing("1", "event1", "Coldplay", 2, "2024-07-01"),
+		testListing("2", "event2", "Hamilton", 1, "2024-08-01"),
+		testListing("3", "event1", "Coldplay", 4, "2024-07-01"),
+	)
+	require.NoError(t, err)
+
+	// Only listings matching the filter should be streamed
+	id, eventType, event := stream.next(t)
+	require.Equal(t, strconv.FormatUint(event.Sequence, 10), id)
+	require.Equal(t, "listing", eventType)
+	require.Equal(t, "1", event.ListingId)
+	require.Equal(t, "Coldplay", event.Listing.Event.Name)
+
+	_, _, event = stream.next(t)
+	require.Equal(t, "3", event.ListingId)
+
+	err = server.Delist(context.Background(), "1", time.Now())
+	require.NoError(t, err)
+
+	_, eventType, event = stream.next(t)
+	require.Equal(t, "delist", eventType)
+	require.Equal(t, StreamEventTypeDelist, event.Type)
+	require.Equal(t, "1", event.ListingId)
+	require.NotNil(t, event.Listing)
+}
+
+func TestStreamResume(t *testing.T) {
+	server, _ := newTestServer(t)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	listing1 := testListing("1", "event1", "Coldplay", 2, "2024-07-01")
+	listing2 := testListing("2", "event2", "Hamilton", 1, "2024-08-01")
+	listing2.CreatedAt = twigots.UnixTime{Time: listing1.CreatedAt.Add(time.Minute)}
+	listing3 := testListing("3", "event1", "Coldplay", 4, "2024-07-01")
+	listing3.CreatedAt = twigots.UnixTime{Time: listing1.CreatedAt.Add(2 * time.Minute)}
+
+	err := server.Process(context.Background(), time.Now(), listing1, listing2, listing3)
+	require.NoError(t, err)
+
+	// Get the sequence of the first event
+	stream := openStream(t, httpServer.URL+"/stream", time.Time{}.Format(time.RFC3339))
+	id, _, event := stream.next(t)
+	require.Equal(t, "1", event.ListingId)
+
+	// Resume by sequence number
+	stream = openStream(t, httpServer.URL+"/stream", id)
+	_, _, event = stream.next(t)
+	require.Equal(t, "2", event.ListingId)
+	_, _, event = stream.next(t)
+	require.Equal(t, "3", event.ListingId)
+
+	// Resume by listing creation time
+	stream = openStream(t, httpServer.URL+"/stream", listing2.CreatedAt.Format(time.RFC3339))
+	_, _, event = stream.next(t)
+	require.Equal(t, "3", event.ListingId)
+}
+
+func TestStreamInvalidLastEventId(t *testing.T) {
+	server, _ := newTestServer(t)
+	request := httptest.NewRequest(http.MethodGet, "/stream", http.NoBody)
+	request.Header.Set("Last-Event-ID", "yesterday")
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestWebSocketStream(t *testing.T) {
+	server, _ := newTestServer(t)
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	err := server.Process(context.Background(), time.Now(), testListing("1", "event1", "Coldplay", 2, "2024-07-01"))
+	require.NoError(t, err)
+
+	websocketURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") +
+		"/stream/ws?numTickets=2&lastEventId=" + time.Time{}.Format(time.RFC3339)
+	conn, err := websocket.Dial(websocketURL, "", httpServer.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	// Backlog should be sent first
+	var event StreamEvent
+	err = websocket.JSON.Receive(conn, &event)
+	require.NoError(t, err)
+	require.Equal(t, "1", event.ListingId)
+
+	// Listings not matching the filter should not be sent
+	err = server.Process(
+		context.Background(),
+		time.Now(),
+		testListing("2", "event2", "Hamilton", 1, "2024-08-01"),
+		testListing("3", "event1", "Coldplay", 2, "2024-07-01"),
+	)
+	require.NoError(t, err)
+
+	err = websocket.JSON.Receive(conn, &event)
+	require.NoError(t, err)
+	require.Equal(t, "3", event.ListingId)
+}
+
+func TestHubDisconnectsSlowSubscribers(t *testing.T) {
+	hub := newHub(10)
+	subscriber, backlog := hub.subscribe(func(twigots.TicketListing) bool { return true }, resumePoint{})
+	require.Empty(t, backlog)
+
+	for i := 0; i <= subscriberQueueSize; i++ {
+		hub.publish(StreamEvent{Type: StreamEventTypeDelist, ListingId: strconv.Itoa(i)})
+	}
+
+	// Queued events should be received, followed by the channel closing
+	numEvents := 0
+	for range subscriber.events {
+		numEvents++
+	}
+	require.Equal(t, subscriberQueueSize, numEvents)
+
+	// Only the most recent events should be buffered
+	afterSequence := uint64(0)
+	_, backlog = hub.subscribe(
+		func(twigots.TicketListing) bool { return true },
+		resumePoint{afterSequence: &afterSequence},
+	)
+	require.Len(t, backlog, 10)
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots/rules"
+)
+
+// ErrWatchNotFound is returned when a watch does not exist.
+var ErrWatchNotFound = errors.New("watch not found")
+
+// Watch is a saved watch. Its rule is evaluated against new ticket listings found by the server.
+type Watch struct {
+	Id        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Rule of the watch. The rule name is a human readable name of the watch, and does not need to be unique.
+	rules.Rule
+}
+
+// Watches is a set of saved watches, optionally persisted to a JSON file.
+type Watches struct {
+	path string
+
+	mutex   sync.RWMutex
+	watches map[string]Watch
+}
+
+// NewWatches creates a set of saved watches persisted to a file at the specified path,
+// loading any existing watches. If path is empty, watches are only kept in memory.
+func NewWatches(path string) (*Watches, error) {
+	watches := &Watches{
+		path:    path,
+		watches: make(map[string]Watch),
+	}
+	if path == "" {
+		return watches, nil
+	}
+
+	watchesJson, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return watches, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watches: %w", err)
+	}
+
+	var watchList []Watch
+	err = json.Unmarshal(watchesJson, &watchList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watches: %w", err)
+	}
+	for _, watch := range watchList {
+		watches.watches[watch.Id] = watch
+	}
+
+	return watches, nil
+}
+
+// List all watches, oldest first.
+func (w *Watches) List() []Watch {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.list()
+}
+
+func (w *Watches) list() []Watch {
+	watches := make([]Watch, 0, len(w.watches))
+	for _, watch := range w.watches {
+		watches = append(watches, watch)
+	}
+	slices.SortFunc(watches, func(a, b Watch) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.Id, b.Id))
+	})
+	return watches
+}
+
+// Get the watch with the specified id, or returns nil if one does not exist.
+func (w *Watches) Get(id string) *Watch {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	watch, ok := w.watches[id]
+	if !ok {
+		return nil
+	}
+	return &watch
+}
+
+// Create a new watch with the specified rule.
+func (w *Watches) Create(rule rules.Rule) (Watch, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	now := time.Now().UTC()
+	watch := Watch{
+		Id:        newId(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Rule:      rule,
+	}
+	w.watches[watch.Id] = watch
+
+	err := w.save()
+	if err != nil {
+		delete(w.watches, watch.Id)
+		return Watch{}, err
+	}
+
+	return watch, nil
+}
+
+// Update the rule of the watch with the specified id.
+// Returns ErrWatchNotFound if the watch does not exist.
+func (w *Watches) Update(id string, rule rules.Rule) (Watch, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	existingWatch, ok := w.watches[id]
+	if !ok {
+		return Watch{}, ErrWatchNotFound
+	}
+
+	watch := existingWatch
+	watch.Rule = rule
+	watch.UpdatedAt = time.Now().UTC()
+	w.watches[id] = watch
+
+	err := w.save()
+	if err != nil {
+		w.watches[id] = existingWatch
+		return Watch{}, err
+	}
+
+	return watch, nil
+}
+
+// Delete the watch with the specified id.
+// Returns ErrWatchNotFound if the watch does not exist.
+func (w *Watches) Delete(id string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	existingWatch, ok := w.watches[id]
+	if !ok {
+		return ErrWatchNotFound
+	}
+	delete(w.watches, id)
+
+	err := w.save()
+	if err != nil {
+		w.watches[id] = existingWatch
+		return err
+	}
+
+	return nil
+}
+
+// save the watches, writing to a temporary file first so watches are never partially written.
+// The mutex must be held when calling this.
+func (w *Watches) save() error {
+	if w.path == "" {
+		return nil
+	}
+
+	watchesJson, err := json.MarshalIndent(w.list(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watches: %w", err)
+	}
+
+	temporaryPath := w.path + ".tmp"
+	err = os.WriteFile(temporaryPath, watchesJson, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write watches: %w", err)
+	}
+
+	err = os.Rename(temporaryPath, w.path)
+	if err != nil {
+		return fmt.Errorf("failed to write watches: %w", err)
+	}
+
+	return nil
+}
+
+func newId() string {
+	randomBytes := make([]byte, 8)
+	_, _ = rand.Read(randomBytes)
+	return hex.EncodeToString(randomBytes)
+}
@@ -0,0 +1,131 @@
+package twigots
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format written by this package.
+const SnapshotVersion = 1
+
+// Snapshot is a point in time record of ticket listings, which can be saved and
+// later compared against newly fetched listings using Diff.
+//
+// Snapshots are serialised as JSON in a stable format: listings are sorted by id
+// and duplicates are removed, so snapshots of the same listings are always identical.
+type Snapshot struct {
+	// Version of the snapshot format.
+	Version int `json:"version"`
+
+	// TakenAt is the time the listings were fetched.
+	TakenAt time.Time `json:"takenAt"`
+
+	// Listings in the snapshot, sorted by id.
+	Listings TicketListings `json:"listings"`
+}
+
+// NewSnapshot creates a snapshot of ticket listings fetched at a time.
+// The listings are copied, sorted by id and deduplicated. The first occurrence of each id is kept.
+func NewSnapshot(listings TicketListings, takenAt time.Time) Snapshot {
+	snapshotListings := make(TicketListings, 0, len(listings))
+	seenIds := make(map[string]bool, len(listings))
+	for _, listing := range listings {
+		if seenIds[listing.Id] {
+			continue
+		}
+		seenIds[listing.Id] = true
+		snapshotListings = append(snapshotListings, listing)
+	}
+
+	slices.SortStableFunc(snapshotListings, func(a, b TicketListing) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+
+	return Snapshot{
+		Version:  SnapshotVersion,
+		TakenAt:  takenAt.UTC(),
+		Listings: snapshotListings,
+	}
+}
+
+// Diff compares the snapshot to newly fetched listings. See Diff.
+func (s Snapshot) Diff(newListings TicketListings) ListingsDiff {
+	return Diff(s.Listings, newListings)
+}
+
+// Write the snapshot as indented JSON.
+func (s Snapshot) Write(w io.Writer) error {
+	snapshotJson, err := json.MarshalIndent(NewSnapshot(s.Listings, s.TakenAt), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	_, err = w.Write(append(snapshotJson, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// ReadSnapshot reads a snapshot written by Snapshot.Write.
+// Returns an error if the snapshot was written in a newer, unsupported format.
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	err := json.NewDecoder(r).Decode(&snapshot)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return Snapshot{}, fmt.Errorf("snapshot version %d is not supported", snapshot.Version)
+	}
+
+	return snapshot, nil
+}
+
+// LoadSnapshot loads a snapshot from a file.
+// If the file does not exist, an empty snapshot is returned.
+func LoadSnapshot(path string) (Snapshot, error) {
+	snapshotFile, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{Version: SnapshotVersion}, nil
+	}
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	defer snapshotFile.Close()
+
+	return ReadSnapshot(snapshotFile)
+}
+
+// Save the snapshot to a file, writing to a temporary file first so the snapshot is never partially written.
+func (s Snapshot) Save(path string) error {
+	temporaryPath := path + ".tmp"
+	snapshotFile, err := os.Create(temporaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	err = s.Write(snapshotFile)
+	closeErr := snapshotFile.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write snapshot: %w", closeErr)
+	}
+
+	err = os.Rename(temporaryPath, path)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
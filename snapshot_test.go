@@ -0,0 +1,67 @@
+package twigots_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotStable(t *testing.T) {
+	listings := testTicketListings(t)
+	takenAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Snapshots of the same listings in any order should be identical
+	var snapshot1, snapshot2 bytes.Buffer
+	err := twigots.NewSnapshot(listings, takenAt).Write(&snapshot1)
+	require.NoError(t, err)
+
+	reversed := twigots.TicketListings{listings[3], listings[2], listings[1], listings[0], listings[1]}
+	err = twigots.NewSnapshot(reversed, takenAt).Write(&snapshot2)
+	require.NoError(t, err)
+	require.Equal(t, snapshot1.String(), snapshot2.String())
+
+	// Reading a snapshot should give the listings sorted by id
+	snapshot, err := twigots.ReadSnapshot(&snapshot1)
+	require.NoError(t, err)
+	require.Equal(t, twigots.SnapshotVersion, snapshot.Version)
+	require.Equal(t, takenAt, snapshot.TakenAt)
+	require.Len(t, snapshot.Listings, 4)
+	for idx := 1; idx < len(snapshot.Listings); idx++ {
+		require.Less(t, snapshot.Listings[idx-1].Id, snapshot.Listings[idx].Id)
+	}
+	require.True(t, snapshot.Diff(listings).IsEmpty())
+}
+
+func TestReadSnapshotUnsupportedVersion(t *testing.T) {
+	_, err := twigots.ReadSnapshot(strings.NewReader(`{"version": 2, "listings": []}`))
+	require.Error(t, err)
+
+	_, err = twigots.ReadSnapshot(strings.NewReader(`{"listings": []}`))
+	require.Error(t, err)
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	listings := testTicketListings(t)
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+
+	// Missing snapshots should be empty
+	snapshot, err := twigots.LoadSnapshot(snapshotPath)
+	require.NoError(t, err)
+	require.Empty(t, snapshot.Listings)
+	require.Len(t, snapshot.Diff(listings).Added, 4)
+
+	err = twigots.NewSnapshot(listings[:2], time.Now()).Save(snapshotPath)
+	require.NoError(t, err)
+
+	snapshot, err = twigots.LoadSnapshot(snapshotPath)
+	require.NoError(t, err)
+	diff := snapshot.Diff(listings[1:])
+	require.Equal(t, twigots.TicketListings{listings[2], listings[3]}, diff.Added)
+	require.Equal(t, twigots.TicketListings{listings[0]}, diff.Removed)
+	require.Empty(t, diff.Changed)
+}
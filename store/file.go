@@ -0,0 +1,447 @@
+package store
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+const (
+	segmentPrefix    = "segment-"
+	segmentExtension = ".jsonl"
+
+	// Default maximum size of a segment before a new segment is started
+	DefaultMaxSegmentSize = 16 * 1024 * 1024 // 16MiB
+)
+
+type operation string
+
+const (
+	operationUpsert operation = "upsert"
+	operationDelist operation = "delist"
+	operationRecord operation = "record" // Written by compaction
+)
+
+// entry is a single line in a segment file.
+type entry struct {
+	Operation operation              `json:"op"`
+	At        time.Time              `json:"at"`
+	Id        string                 `json:"id,omitempty"`
+	Listing   *twigots.TicketListing `json:"listing,omitempty"`
+	Record    *Record                `json:"record,omitempty"`
+}
+
+// FileStore is a Store backed by append-only JSONL segment files in a directory.
+//
+// Every upsert and delist is appended to the latest segment file. When a segment exceeds
+// MaxSegmentSize, a new segment is started. An index of all records is kept in memory
+// and is rebuilt from the segments when the store is opened.
+//
+// Use Compact to rewrite all segments into a single segment containing only the latest records.
+type FileStore struct {
+	// MaxSegmentSize is the size in bytes after which a new segment file is started.
+	// Defaults to DefaultMaxSegmentSize.
+	MaxSegmentSize int64
+
+	directory string
+
+	mutex         sync.RWMutex
+	index         map[string]*Record
+	segment       *os.File
+	segmentNumber int
+	segmentSize   int64
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore opens a file store in the specified directory, creating the directory if it does not exist.
+func NewFileStore(directory string) (*FileStore, error) {
+	err := os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	store := &FileStore{
+		MaxSegmentSize: DefaultMaxSegmentSize,
+		directory:      directory,
+		index:          make(map[string]*Record),
+	}
+
+	segmentNumbers, err := store.segmentNumbers()
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, segmentNumber := range segmentNumbers {
+		err := store.replaySegment(segmentNumber, idx == len(segmentNumbers)-1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	latestSegmentNumber := 1
+	if len(segmentNumbers) != 0 {
+		latestSegmentNumber = segmentNumbers[len(segmentNumbers)-1]
+	}
+
+	err = store.openSegment(latestSegmentNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (s *FileStore) Upsert(_ context.Context, seenAt time.Time, listings ...twigots.TicketListing) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, listing := range listings {
+		err := s.append(entry{
+			Operation: operationUpsert,
+			At:        seenAt,
+			Listing:   &listing,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *FileStore) Delist(_ context.Context, id string, delistedAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.append(entry{
+		Operation: operationDelist,
+		At:        delistedAt,
+		Id:        id,
+	})
+}
+
+func (s *FileStore) Get(_ context.Context, id string) (*Record, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	record, ok := s.index[id]
+	if !ok {
+		return nil, nil
+	}
+
+	recordCopy := cloneRecord(*record)
+	return &recordCopy, nil
+}
+
+func (s *FileStore) Query(_ context.Context, query Query) ([]Record, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	records := make([]Record, 0, len(s.index))
+	for _, record := range s.index {
+		if query.Matches(*record) {
+			records = append(records, cloneRecord(*record))
+		}
+	}
+
+	slices.SortFunc(records, func(a, b Record) int {
+		return cmp.Or(
+			b.Listing.CreatedAt.Compare(a.Listing.CreatedAt.Time),
+			cmp.Compare(a.Listing.Id, b.Listing.Id),
+		)
+	})
+
+	return records, nil
+}
+
+// Compact rewrites all segments into a single new segment containing only the
+// latest record of each ticket listing, then removes the old segments.
+func (s *FileStore) Compact(_ context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	oldSegmentNumbers, err := s.segmentNumbers()
+	if err != nil {
+		return err
+	}
+
+	// Write compacted segment to a temporary file first, so a failure
+	// part way through does not lose any data.
+	compactedSegmentNumber := s.segmentNumber + 1
+	compactedSegmentPath := s.segmentPath(compactedSegmentNumber)
+	temporaryPath := compactedSegmentPath + ".tmp"
+
+	err = s.writeCompactedSegment(temporaryPath)
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+
+	// If compaction fails from here on, the current segment is reopened
+	// so the store can still be appended to.
+	err = s.segment.Close()
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return errors.Join(fmt.Errorf("failed to close segment: %w", err), s.openSegment(s.segmentNumber))
+	}
+
+	err = os.Rename(temporaryPath, compactedSegmentPath)
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return errors.Join(fmt.Errorf("failed to rename compacted segment: %w", err), s.openSegment(s.segmentNumber))
+	}
+
+	// The compacted segment now contains all records, so is used even if old segments cannot be removed.
+	// Any old segments left behind are replayed before the compacted segment, so do not change any records.
+	var removeErr error
+	for _, segmentNumber := range oldSegmentNumbers {
+		err := os.Remove(s.segmentPath(segmentNumber))
+		if err != nil {
+			removeErr = fmt.Errorf("failed to remove old segment: %w", err)
+			break
+		}
+	}
+
+	return errors.Join(removeErr, s.openSegment(compactedSegmentNumber))
+}
+
+func (s *FileStore) writeCompactedSegment(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted segment: %w", err)
+	}
+	defer file.Close()
+
+	ids := make([]string, 0, len(s.index))
+	for id := range s.index {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, id := range ids {
+		record := s.index[id]
+		err := encoder.Encode(entry{
+			Operation: operationRecord,
+			At:        record.LastSeenAt,
+			Id:        id,
+			Record:    record,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write compacted segment: %w", err)
+	}
+
+	return file.Sync()
+}
+
+func (s *FileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.segment.Close()
+}
+
+// append an entry to the current segment and apply it to the index.
+// The mutex must be held when calling this.
+func (s *FileStore) append(e entry) error {
+	entryJson, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	entryJson = append(entryJson, '\n')
+
+	maxSegmentSize := s.MaxSegmentSize
+	if maxSegmentSize <= 0 {
+		maxSegmentSize = DefaultMaxSegmentSize
+	}
+
+	if s.segmentSize > 0 && s.segmentSize+int64(len(entryJson)) > maxSegmentSize {
+		err := s.segment.Close()
+		if err != nil {
+			return fmt.Errorf("failed to close segment: %w", err)
+		}
+
+		err = s.openSegment(s.segmentNumber + 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	numBytes, err := s.segment.Write(entryJson)
+	s.segmentSize += int64(numBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+
+	s.apply(e)
+	return nil
+}
+
+// apply an entry to the index.
+func (s *FileStore) apply(e entry) {
+	switch e.Operation {
+	case operationUpsert:
+		if e.Listing == nil {
+			return
+		}
+		s.index[e.Listing.Id] = updateRecord(s.index[e.Listing.Id], *e.Listing, e.At)
+
+	case operationDelist:
+		record, ok := s.index[e.Id]
+		if !ok || record.Delisted() {
+			return
+		}
+		delistedAt := e.At
+		record.DelistedAt = &delistedAt
+
+	case operationRecord:
+		if e.Record == nil {
+			return
+		}
+		s.index[e.Id] = e.Record
+	}
+}
+
+// replaySegment reads all entries in a segment, applying them to the index.
+//
+// If the segment is the active (latest) segment, a final line without a trailing newline is an entry
+// that was only partly written (e.g. due to a crash), so is truncated from the segment rather than failing.
+// Any other line that cannot be read is corruption, and an error is returned.
+func (s *FileStore) replaySegment(segmentNumber int, active bool) error {
+	path := s.segmentPath(segmentNumber)
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	offset := int64(0)
+	lineNumber := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) != 0 {
+			lineNumber++
+			if !active {
+				return fmt.Errorf(
+					"failed to read segment %s line %d: %w",
+					filepath.Base(path), lineNumber, io.ErrUnexpectedEOF,
+				)
+			}
+			return truncateSegment(path, offset)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read segment: %w", err)
+		}
+		lineNumber++
+		offset += int64(len(line))
+
+		line = line[:len(line)-1]
+		if len(line) == 0 {
+			continue
+		}
+
+		var e entry
+		err = json.Unmarshal(line, &e)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to read segment %s line %d: %w",
+				filepath.Base(path), lineNumber, err,
+			)
+		}
+
+		s.apply(e)
+	}
+}
+
+// truncateSegment truncates a partly written entry from the end of a segment.
+func truncateSegment(path string, size int64) error {
+	err := os.Truncate(path, size)
+	if err != nil {
+		return fmt.Errorf("failed to truncate partly written entry from segment: %w", err)
+	}
+	return nil
+}
+
+// openSegment opens a segment for appending, creating it if it does not exist.
+func (s *FileStore) openSegment(segmentNumber int) error {
+	segment, err := os.OpenFile(s.segmentPath(segmentNumber), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %w", err)
+	}
+
+	info, err := segment.Stat()
+	if err != nil {
+		_ = segment.Close()
+		return fmt.Errorf("failed to stat segment: %w", err)
+	}
+
+	s.segment = segment
+	s.segmentNumber = segmentNumber
+	s.segmentSize = info.Size()
+	return nil
+}
+
+// segmentNumbers gets the numbers of all existing segments in ascending order.
+func (s *FileStore) segmentNumbers() ([]int, error) {
+	dirEntries, err := os.ReadDir(s.directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store directory: %w", err)
+	}
+
+	segmentNumbers := make([]int, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() ||
+			!strings.HasPrefix(name, segmentPrefix) ||
+			!strings.HasSuffix(name, segmentExtension) {
+			continue
+		}
+
+		var segmentNumber int
+		_, err := fmt.Sscanf(name, segmentPrefix+"%d"+segmentExtension, &segmentNumber)
+		if err != nil {
+			return nil, errors.New("invalid segment file name: " + name)
+		}
+		segmentNumbers = append(segmentNumbers, segmentNumber)
+	}
+
+	slices.Sort(segmentNumbers)
+	return segmentNumbers, nil
+}
+
+func (s *FileStore) segmentPath(segmentNumber int) string {
+	return filepath.Join(s.directory, fmt.Sprintf("%s%06d%s", segmentPrefix, segmentNumber, segmentExtension))
+}
+
+func cloneRecord(record Record) Record {
+	record.History = slices.Clone(record.History)
+	if record.DelistedAt != nil {
+		delistedAt := *record.DelistedAt
+		record.DelistedAt = &delistedAt
+	}
+	return record
+}
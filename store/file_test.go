@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	testTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(directory)
+	require.NoError(t, err)
+
+	listing1 := testListing("1", "event1", "tour1", testTime.Add(-2*time.Minute), 2, 100)
+	listing2 := testListing("2", "event2", "tour1", testTime.Add(-time.Minute), 1, 50)
+	err = store.Upsert(ctx, testTime, listing1, listing2)
+	require.NoError(t, err)
+
+	// Seeing the same listing again should not add history
+	err = store.Upsert(ctx, testTime.Add(time.Minute), listing1)
+	require.NoError(t, err)
+
+	// Price drop should add history
+	listing1.TotalPriceExclFee.Amount = 8000
+	err = store.Upsert(ctx, testTime.Add(2*time.Minute), listing1)
+	require.NoError(t, err)
+
+	err = store.Delist(ctx, "2", testTime.Add(3*time.Minute))
+	require.NoError(t, err)
+
+	assertRecords := func(t *testing.T, store *FileStore) {
+		t.Helper()
+
+		record, err := store.Get(ctx, "1")
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		require.False(t, record.Delisted())
+		require.Equal(t, testTime, record.FirstSeenAt.UTC())
+		require.Equal(t, testTime.Add(2*time.Minute), record.LastSeenAt.UTC())
+		require.Len(t, record.History, 2)
+		require.Equal(t, int64(10000), record.History[0].TotalPriceExclFee.Amount)
+		require.Equal(t, int64(8000), record.History[1].TotalPriceExclFee.Amount)
+		require.Equal(t, int64(8000), record.Listing.TotalPriceExclFee.Amount)
+
+		record, err = store.Get(ctx, "2")
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		require.True(t, record.Delisted())
+
+		record, err = store.Get(ctx, "3")
+		require.NoError(t, err)
+		require.Nil(t, record)
+
+		records, err := store.Query(ctx, Query{TourId: "tour1"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "1", records[0].Listing.Id)
+
+		records, err = store.Query(ctx, Query{TourId: "tour1", IncludeDelisted: true})
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "2", records[0].Listing.Id) // Newest first
+		require.Equal(t, "1", records[1].Listing.Id)
+
+		records, err = store.Query(ctx, Query{
+			CreatedAfter:    testTime.Add(-90 * time.Second),
+			IncludeDelisted: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "2", records[0].Listing.Id)
+	}
+	assertRecords(t, store)
+
+	// Reopen store and check index is rebuilt
+	err = store.Close()
+	require.NoError(t, err)
+	store, err = NewFileStore(directory)
+	require.NoError(t, err)
+	assertRecords(t, store)
+
+	// Compact store and check records are unchanged
+	err = store.Compact(ctx)
+	require.NoError(t, err)
+	assertRecords(t, store)
+
+	dirEntries, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, 1)
+
+	// Reopen compacted store, check records are unchanged, and that new entries can be added
+	err = store.Close()
+	require.NoError(t, err)
+	store, err = NewFileStore(directory)
+	require.NoError(t, err)
+	assertRecords(t, store)
+
+	err = store.Upsert(ctx, testTime.Add(4*time.Minute), testListing("3", "event3", "tour2", testTime, 1, 10))
+	require.NoError(t, err)
+	record, err := store.Get(ctx, "3")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+
+	err = store.Close()
+	require.NoError(t, err)
+}
+
+func TestFileStoreSegmentRollover(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	testTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(directory)
+	require.NoError(t, err)
+	store.MaxSegmentSize = 1 // Every entry should be in its own segment
+
+	for _, id := range []string{"1", "2", "3"} {
+		err := store.Upsert(ctx, testTime, testListing(id, "event", "tour", testTime, 1, 10))
+		require.NoError(t, err)
+	}
+
+	dirEntries, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, dirEntries, 3)
+
+	err = store.Close()
+	require.NoError(t, err)
+	store, err = NewFileStore(directory)
+	require.NoError(t, err)
+
+	records, err := store.Query(ctx, Query{})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	err = store.Close()
+	require.NoError(t, err)
+}
+
+func TestFileStoreTruncatedRecord(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	testTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(directory)
+	require.NoError(t, err)
+	for _, id := range []string{"1", "2"} {
+		err := store.Upsert(ctx, testTime, testListing(id, "event", "tour", testTime, 1, 10))
+		require.NoError(t, err)
+	}
+	path := store.segmentPath(store.segmentNumber)
+	err = store.Close()
+	require.NoError(t, err)
+
+	// Simulate a crash part way through writing the last record
+	segment, err := os.ReadFile(path)
+	require.NoError(t, err)
+	truncatedSize := len(segment) - 10
+	err = os.WriteFile(path, segment[:truncatedSize], 0o644)
+	require.NoError(t, err)
+
+	// The partly written record should be dropped, and the rest of the segment kept
+	store, err = NewFileStore(directory)
+	require.NoError(t, err)
+	records, err := store.Query(ctx, Query{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "1", records[0].Listing.Id)
+
+	// New records should be appended after the truncated record
+	err = store.Upsert(ctx, testTime, testListing("3", "event", "tour", testTime, 1, 10))
+	require.NoError(t, err)
+	err = store.Close()
+	require.NoError(t, err)
+	store, err = NewFileStore(directory)
+	require.NoError(t, err)
+	records, err = store.Query(ctx, Query{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	err = store.Close()
+	require.NoError(t, err)
+
+	// Corruption in the middle of a segment should still fail
+	segment, err = os.ReadFile(path)
+	require.NoError(t, err)
+	segment[5] = '!'
+	err = os.WriteFile(path, segment, 0o644)
+	require.NoError(t, err)
+	_, err = NewFileStore(directory)
+	require.Error(t, err)
+}
+
+func TestFileStoreCompactFailure(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+	testTime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileStore(directory)
+	require.NoError(t, err)
+
+	err = store.Upsert(ctx, testTime, testListing("1", "event", "tour", testTime, 1, 10))
+	require.NoError(t, err)
+
+	// Block the compacted segment path with a non-empty directory, so renaming fails
+	blockingPath := store.segmentPath(store.segmentNumber + 1)
+	err = os.MkdirAll(filepath.Join(blockingPath, "blocking"), 0o755)
+	require.NoError(t, err)
+
+	err = store.Compact(ctx)
+	require.Error(t, err)
+
+	// The store should still be usable after compaction fails
+	err = store.Upsert(ctx, testTime, testListing("2", "event", "tour", testTime, 1, 20))
+	require.NoError(t, err)
+
+	err = os.RemoveAll(blockingPath)
+	require.NoError(t, err)
+	err = store.Close()
+	require.NoError(t, err)
+
+	store, err = NewFileStore(directory)
+	require.NoError(t, err)
+
+	records, err := store.Query(ctx, Query{})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	// Compaction should succeed once unblocked
+	err = store.Compact(ctx)
+	require.NoError(t, err)
+	err = store.Upsert(ctx, testTime, testListing("3", "event", "tour", testTime, 1, 30))
+	require.NoError(t, err)
+
+	err = store.Close()
+	require.NoError(t, err)
+}
+
+func testListing(
+	id, eventId, tourId string,
+	createdAt time.Time,
+	numTickets, totalPrice int,
+) twigots.TicketListing {
+	return twigots.TicketListing{
+		Id:         id,
+		CreatedAt:  twigots.UnixTime{Time: createdAt},
+		NumTickets: numTickets,
+		TotalPriceExclFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 100,
+		},
+		TwicketsFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 10,
+		},
+		Event: twigots.Event{Id: eventId},
+		Tour:  twigots.Tour{Id: tourId},
+	}
+}
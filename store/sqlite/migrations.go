@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order. Each migration is applied at most once, and its
+// index (starting at 1) is recorded as the schema version once it has been applied.
+//
+// Never modify an existing migration - always add a new one.
+var migrations = []string{
+	// 1: Initial schema
+	`
+	CREATE TABLE artists (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		slug TEXT NOT NULL
+	);
+
+	CREATE TABLE venues (
+		id                 TEXT PRIMARY KEY,
+		name               TEXT NOT NULL,
+		postcode           TEXT NOT NULL,
+		location_id        TEXT NOT NULL,
+		location_name      TEXT NOT NULL,
+		location_full_name TEXT NOT NULL,
+		country            TEXT NOT NULL,
+		region             TEXT NOT NULL,
+		latitude           REAL NOT NULL,
+		longitude          REAL NOT NULL,
+		time_zone          TEXT NOT NULL
+	);
+	CREATE INDEX venues_region ON venues (region);
+
+	CREATE TABLE events (
+		id           TEXT PRIMARY KEY,
+		name         TEXT NOT NULL,
+		category     TEXT NOT NULL,
+		date         TEXT NOT NULL,
+		start_time   TEXT NOT NULL,
+		on_sale_at   INTEGER,
+		announced_at INTEGER,
+		venue_id     TEXT NOT NULL REFERENCES venues (id)
+	);
+	CREATE INDEX events_venue_id ON events (venue_id);
+
+	CREATE TABLE lineup (
+		event_id  TEXT NOT NULL REFERENCES events (id),
+		artist_id TEXT NOT NULL REFERENCES artists (id),
+		billing   INTEGER NOT NULL,
+		PRIMARY KEY (event_id, artist_id)
+	);
+
+	CREATE TABLE tours (
+		id              TEXT PRIMARY KEY,
+		name            TEXT NOT NULL,
+		slug            TEXT NOT NULL,
+		first_event     TEXT,
+		last_event      TEXT,
+		countries       TEXT NOT NULL -- JSON array of country codes
+	);
+
+	CREATE TABLE listings (
+		id                          TEXT PRIMARY KEY,
+		created_at                  INTEGER NOT NULL, -- Unix milliseconds
+		expires_at                  INTEGER NOT NULL, -- Unix milliseconds
+		num_tickets                 INTEGER NOT NULL,
+		currency                    TEXT NOT NULL,
+		total_price_excl_fee        INTEGER NOT NULL, -- Minor units e.g. pence
+		twickets_fee                INTEGER NOT NULL, -- Minor units e.g. pence
+		original_total_price        INTEGER NOT NULL, -- Minor units e.g. pence
+		seller_will_consider_offers INTEGER NOT NULL,
+		ticket_type                 TEXT NOT NULL,
+		seat_assigned               INTEGER NOT NULL,
+		section                     TEXT NOT NULL,
+		row                         TEXT NOT NULL,
+		event_id                    TEXT NOT NULL REFERENCES events (id),
+		tour_id                     TEXT NOT NULL REFERENCES tours (id),
+		region                      TEXT NOT NULL, -- Denormalised from venue for fast filtering
+		first_seen_at               INTEGER NOT NULL, -- Unix milliseconds
+		last_seen_at                INTEGER NOT NULL, -- Unix milliseconds
+		delisted_at                 INTEGER           -- Unix milliseconds
+	);
+	CREATE INDEX listings_event_id ON listings (event_id);
+	CREATE INDEX listings_tour_id ON listings (tour_id);
+	CREATE INDEX listings_created_at ON listings (created_at);
+	CREATE INDEX listings_region ON listings (region);
+
+	CREATE TABLE listing_history (
+		listing_id           TEXT NOT NULL REFERENCES listings (id),
+		seen_at              INTEGER NOT NULL, -- Unix milliseconds
+		num_tickets          INTEGER NOT NULL,
+		currency             TEXT NOT NULL,
+		total_price_excl_fee INTEGER NOT NULL,
+		twickets_fee         INTEGER NOT NULL
+	);
+	CREATE INDEX listing_history_listing_id ON listing_history (listing_id, seen_at);
+	`,
+
+	// 2: Add selling prices used to track re-pricing
+	`
+	ALTER TABLE listings ADD COLUMN original_selling_price INTEGER NOT NULL DEFAULT 0; -- Minor units e.g. pence
+	ALTER TABLE listings ADD COLUMN previous_selling_price INTEGER NOT NULL DEFAULT 0; -- Minor units e.g. pence
+	`,
+
+	// 3: Add fee applicable price and reported fee used to detect fee drift
+	`
+	ALTER TABLE listings ADD COLUMN total_price_fee_applicable INTEGER NOT NULL DEFAULT 0; -- Minor units e.g. pence
+	ALTER TABLE listings ADD COLUMN reported_twickets_fee_per_ticket INTEGER NOT NULL DEFAULT 0; -- Minor units e.g. pence
+	`,
+}
+
+// migrate applies all migrations that have not yet been applied to the database.
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema version table: %w", err)
+	}
+
+	var version int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		err := applyMigration(ctx, db, i+1, migrations[i])
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int, migration string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	_, err = tx.ExecContext(ctx, migration)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
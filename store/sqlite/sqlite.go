@@ -0,0 +1,692 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/store"
+	_ "modernc.org/sqlite" // Pure go sqlite driver
+)
+
+const (
+	driverName = "sqlite"
+
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04:05"
+)
+
+// Store is a store.Store backed by an SQLite database.
+//
+// Ticket listings are stored in a normalised schema, with separate tables for listings,
+// listing history, events, venues, tours, artists and event lineups. This allows the database
+// to be queried directly using SQL. See migrations.go for the full schema.
+type Store struct {
+	db *sql.DB
+}
+
+var _ store.Store = (*Store)(nil)
+
+// Open opens an SQLite store at the specified path, creating the database if it does not exist,
+// and applying any outstanding migrations.
+//
+// Use ":memory:" as the path for a temporary in-memory database.
+func Open(ctx context.Context, path string) (*Store, error) {
+	dataSourceName := path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if path != ":memory:" {
+		dataSourceName += "&_pragma=journal_mode(WAL)"
+	}
+
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite only supports a single writer, and in memory databases
+	// are not shared between connections.
+	db.SetMaxOpenConns(1)
+
+	err = migrate(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// DB gets the underlying database. Use this to run your own SQL queries.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Upsert(ctx context.Context, seenAt time.Time, listings ...twigots.TicketListing) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // nolint: errcheck
+
+	for _, listing := range listings {
+		err := upsertListing(ctx, tx, listing, seenAt)
+		if err != nil {
+			return fmt.Errorf("failed to upsert listing %s: %w", listing.Id, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Delist(ctx context.Context, id string, delistedAt time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE listings SET delisted_at = ? WHERE id = ? AND delisted_at IS NULL`,
+		delistedAt.UnixMilli(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delist listing %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, id string) (*store.Record, error) {
+	records, err := s.queryRecords(ctx, `l.id = ?`, []any{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+func (s *Store) Query(ctx context.Context, query store.Query) ([]store.Record, error) {
+	conditions, args := queryConditions(query)
+	return s.queryRecords(ctx, conditions, args)
+}
+
+// PriceHistory gets the history of the ticket listing with the specified id, oldest first.
+func (s *Store) PriceHistory(ctx context.Context, id string) ([]store.HistoryEntry, error) {
+	historyByListing, err := s.history(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return historyByListing[id], nil
+}
+
+// EventSummary is a summary of the ticket listings of an event in a single currency.
+type EventSummary struct {
+	EventId   string
+	EventName string
+	EventDate string
+
+	NumListings int
+	NumTickets  int
+
+	// Minimum and maximum total price of a listing, including fee.
+	// Both are in the currency of the summarised listings.
+	MinTotalPriceInclFee twigots.Price
+	MaxTotalPriceInclFee twigots.Price
+}
+
+// EventSummaries gets a summary of the ticket listings of each event matching the query,
+// ordered by the number of listings (most first).
+// An event with listings in several currencies has a separate summary for each currency,
+// so prices in different currencies are never combined.
+func (s *Store) EventSummaries(ctx context.Context, query store.Query) ([]EventSummary, error) {
+	conditions, args := queryConditions(query)
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT
+			e.id, e.name, e.date,
+			COUNT(*),
+			SUM(l.num_tickets),
+			l.currency,
+			MIN(l.total_price_excl_fee + l.twickets_fee),
+			MAX(l.total_price_excl_fee + l.twickets_fee)
+		FROM listings l
+		JOIN events e ON e.id = l.event_id
+		WHERE `+conditions+`
+		GROUP BY e.id, l.currency
+		ORDER BY COUNT(*) DESC, e.date, e.id, l.currency`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []EventSummary
+	for rows.Next() {
+		var summary EventSummary
+		var currencyCode string
+		err := rows.Scan(
+			&summary.EventId, &summary.EventName, &summary.EventDate,
+			&summary.NumListings, &summary.NumTickets,
+			&currencyCode,
+			&summary.MinTotalPriceInclFee.Amount,
+			&summary.MaxTotalPriceInclFee.Amount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event summary: %w", err)
+		}
+
+		currency := parseCurrency(currencyCode)
+		summary.MinTotalPriceInclFee.Currency = currency
+		summary.MaxTotalPriceInclFee.Currency = currency
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
+
+func upsertListing(ctx context.Context, tx *sql.Tx, listing twigots.TicketListing, seenAt time.Time) error {
+	event := listing.Event
+	venue := event.Venue
+	location := venue.Location
+	tour := listing.Tour
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO venues (
+			id, name, postcode, location_id, location_name, location_full_name,
+			country, region, latitude, longitude, time_zone
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			postcode = excluded.postcode,
+			location_id = excluded.location_id,
+			location_name = excluded.location_name,
+			location_full_name = excluded.location_full_name,
+			country = excluded.country,
+			region = excluded.region,
+			latitude = excluded.latitude,
+			longitude = excluded.longitude,
+			time_zone = excluded.time_zone`,
+		venue.Id, venue.Name, venue.Postcode, location.Id, location.Name, location.FullName,
+		location.Country.Value, location.Region.Value, location.Latitude, location.Longitude, location.TimeZone,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert venue: %w", err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO events (id, name, category, date, start_time, on_sale_at, announced_at, venue_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			category = excluded.category,
+			date = excluded.date,
+			start_time = excluded.start_time,
+			on_sale_at = excluded.on_sale_at,
+			announced_at = excluded.announced_at,
+			venue_id = excluded.venue_id`,
+		event.Id, event.Name, event.Category,
+		event.Date.Format(dateLayout), event.Time.Format(timeLayout),
+		nullableDateTime(event.OnSale), nullableDateTime(event.Announced),
+		venue.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert event: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM lineup WHERE event_id = ?`, event.Id)
+	if err != nil {
+		return fmt.Errorf("failed to delete lineup: %w", err)
+	}
+	for _, lineup := range event.Lineup {
+		artist := lineup.Artist
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO artists (id, name, slug) VALUES (?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name, slug = excluded.slug`,
+			artist.Id, artist.Name, artist.Slug,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to upsert artist: %w", err)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT OR REPLACE INTO lineup (event_id, artist_id, billing) VALUES (?, ?, ?)`,
+			event.Id, artist.Id, lineup.Billing,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert lineup: %w", err)
+		}
+	}
+
+	tourCountries, err := json.Marshal(tour.Countries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tour countries: %w", err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO tours (id, name, slug, first_event, last_event, countries)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			slug = excluded.slug,
+			first_event = excluded.first_event,
+			last_event = excluded.last_event,
+			countries = excluded.countries`,
+		tour.Id, tour.Name, tour.Slug,
+		nullableDate(tour.FirstEvent), nullableDate(tour.LastEvent),
+		string(tourCountries),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert tour: %w", err)
+	}
+
+	seenAtMilli := seenAt.UnixMilli()
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO listings (
+			id, created_at, expires_at, num_tickets, currency,
+			total_price_excl_fee, twickets_fee, original_total_price,
+			original_selling_price, previous_selling_price,
+			total_price_fee_applicable, reported_twickets_fee_per_ticket,
+			seller_will_consider_offers, ticket_type, seat_assigned, section, row,
+			event_id, tour_id, region, first_seen_at, last_seen_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at,
+			num_tickets = excluded.num_tickets,
+			currency = excluded.currency,
+			total_price_excl_fee = excluded.total_price_excl_fee,
+			twickets_fee = excluded.twickets_fee,
+			original_total_price = excluded.original_total_price,
+			original_selling_price = excluded.original_selling_price,
+			previous_selling_price = excluded.previous_selling_price,
+			total_price_fee_applicable = excluded.total_price_fee_applicable,
+			reported_twickets_fee_per_ticket = excluded.reported_twickets_fee_per_ticket,
+			seller_will_consider_offers = excluded.seller_will_consider_offers,
+			ticket_type = excluded.ticket_type,
+			seat_assigned = excluded.seat_assigned,
+			section = excluded.section,
+			row = excluded.row,
+			event_id = excluded.event_id,
+			tour_id = excluded.tour_id,
+			region = excluded.region,
+			last_seen_at = MAX(last_seen_at, excluded.last_seen_at),
+			delisted_at = CASE WHEN delisted_at < excluded.last_seen_at THEN NULL ELSE delisted_at END`,
+		listing.Id, listing.CreatedAt.UnixMilli(), listing.ExpiresAt.UnixMilli(),
+		listing.NumTickets, listing.TotalPriceExclFee.Currency.Value,
+		listing.TotalPriceExclFee.Amount, listing.TwicketsFee.Amount, listing.OriginalTotalPrice.Amount,
+		listing.OriginalSellingPrice.Amount, listing.PreviousSellingPrice.Amount,
+		listing.TotalPriceFeeApplicable.Amount, listing.ReportedTwicketsFeePerTicket.Amount,
+		listing.SellerWillConsiderOffers, listing.TicketType, listing.SeatAssigned,
+		listing.Section, listing.Row,
+		event.Id, tour.Id, location.Region.Value,
+		seenAtMilli, seenAtMilli,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert listing: %w", err)
+	}
+
+	// Add history entry if this is the first time the listing has been seen, or anything has changed
+	var numTickets int
+	var totalPriceExclFee, twicketsFee int64
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT num_tickets, total_price_excl_fee, twickets_fee
+		FROM listing_history
+		WHERE listing_id = ?
+		ORDER BY seen_at DESC, rowid DESC
+		LIMIT 1`,
+		listing.Id,
+	).Scan(&numTickets, &totalPriceExclFee, &twicketsFee)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get latest history: %w", err)
+	}
+	if errors.Is(err, sql.ErrNoRows) ||
+		numTickets != listing.NumTickets ||
+		totalPriceExclFee != listing.TotalPriceExclFee.Amount ||
+		twicketsFee != listing.TwicketsFee.Amount {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO listing_history (
+				listing_id, seen_at, num_tickets, currency, total_price_excl_fee, twickets_fee
+			) VALUES (?, ?, ?, ?, ?, ?)`,
+			listing.Id, seenAtMilli, listing.NumTickets, listing.TotalPriceExclFee.Currency.Value,
+			listing.TotalPriceExclFee.Amount, listing.TwicketsFee.Amount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert history: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// queryConditions converts a query to an SQL where clause (on the listings table aliased as l) and its args.
+func queryConditions(query store.Query) (string, []any) {
+	conditions := []string{"1 = 1"}
+	var args []any
+
+	if query.EventId != "" {
+		conditions = append(conditions, "l.event_id = ?")
+		args = append(args, query.EventId)
+	}
+	if query.TourId != "" {
+		conditions = append(conditions, "l.tour_id = ?")
+		args = append(args, query.TourId)
+	}
+	if query.Region.Value != "" {
+		conditions = append(conditions, "l.region = ?")
+		args = append(args, query.Region.Value)
+	}
+	if !query.CreatedAfter.IsZero() {
+		conditions = append(conditions, "l.created_at > ?")
+		args = append(args, query.CreatedAfter.UnixMilli())
+	}
+	if !query.CreatedBefore.IsZero() {
+		conditions = append(conditions, "l.created_at < ?")
+		args = append(args, query.CreatedBefore.UnixMilli())
+	}
+	if !query.IncludeDelisted {
+		conditions = append(conditions, "l.delisted_at IS NULL")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// queryRecords gets the records of all listings matching the where clause.
+func (s *Store) queryRecords(ctx context.Context, conditions string, args []any) ([]store.Record, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT
+			l.id, l.created_at, l.expires_at, l.num_tickets, l.currency,
+			l.total_price_excl_fee, l.twickets_fee, l.original_total_price,
+			l.original_selling_price, l.previous_selling_price,
+			l.total_price_fee_applicable, l.reported_twickets_fee_per_ticket,
+			l.seller_will_consider_offers, l.ticket_type, l.seat_assigned, l.section, l.row,
+			l.first_seen_at, l.last_seen_at, l.delisted_at,
+			e.id, e.name, e.category, e.date, e.start_time, e.on_sale_at, e.announced_at,
+			v.id, v.name, v.postcode, v.location_id, v.location_name, v.location_full_name,
+			v.country, v.region, v.latitude, v.longitude, v.time_zone,
+			t.id, t.name, t.slug, t.first_event, t.last_event, t.countries
+		FROM listings l
+		JOIN events e ON e.id = l.event_id
+		JOIN venues v ON v.id = e.venue_id
+		JOIN tours t ON t.id = l.tour_id
+		WHERE `+conditions+`
+		ORDER BY l.created_at DESC, l.id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listings: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.Record
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan listing: %w", err)
+		}
+		records = append(records, record)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listings: %w", err)
+	}
+
+	// Populate history and lineups
+	listingIds := make([]string, 0, len(records))
+	eventIds := make([]string, 0, len(records))
+	for _, record := range records {
+		listingIds = append(listingIds, record.Listing.Id)
+		eventIds = append(eventIds, record.Listing.Event.Id)
+	}
+
+	historyByListing, err := s.history(ctx, listingIds)
+	if err != nil {
+		return nil, err
+	}
+
+	lineupByEvent, err := s.lineups(ctx, eventIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		records[i].History = historyByListing[records[i].Listing.Id]
+		records[i].Listing.Event.Lineup = lineupByEvent[records[i].Listing.Event.Id]
+	}
+
+	return records, nil
+}
+
+func scanRecord(rows *sql.Rows) (store.Record, error) {
+	var record store.Record
+	listing := &record.Listing
+	event := &listing.Event
+	venue := &event.Venue
+	location := &venue.Location
+	tour := &listing.Tour
+
+	var createdAt, expiresAt, firstSeenAt, lastSeenAt int64
+	var delistedAt, onSaleAt, announcedAt sql.NullInt64
+	var currencyCode, eventDate, eventStartTime, countryCode, regionCode, tourCountries string
+	var tourFirstEvent, tourLastEvent sql.NullString
+	err := rows.Scan(
+		&listing.Id, &createdAt, &expiresAt, &listing.NumTickets, &currencyCode,
+		&listing.TotalPriceExclFee.Amount, &listing.TwicketsFee.Amount, &listing.OriginalTotalPrice.Amount,
+		&listing.OriginalSellingPrice.Amount, &listing.PreviousSellingPrice.Amount,
+		&listing.TotalPriceFeeApplicable.Amount, &listing.ReportedTwicketsFeePerTicket.Amount,
+		&listing.SellerWillConsiderOffers, &listing.TicketType, &listing.SeatAssigned,
+		&listing.Section, &listing.Row,
+		&firstSeenAt, &lastSeenAt, &delistedAt,
+		&event.Id, &event.Name, &event.Category, &eventDate, &eventStartTime, &onSaleAt, &announcedAt,
+		&venue.Id, &venue.Name, &venue.Postcode, &location.Id, &location.Name, &location.FullName,
+		&countryCode, &regionCode, &location.Latitude, &location.Longitude, &location.TimeZone,
+		&tour.Id, &tour.Name, &tour.Slug, &tourFirstEvent, &tourLastEvent, &tourCountries,
+	)
+	if err != nil {
+		return store.Record{}, err
+	}
+
+	listing.CreatedAt = twigots.UnixTime{Time: time.UnixMilli(createdAt)}
+	listing.ExpiresAt = twigots.UnixTime{Time: time.UnixMilli(expiresAt)}
+
+	currency := parseCurrency(currencyCode)
+	listing.TotalPriceExclFee.Currency = currency
+	listing.TwicketsFee.Currency = currency
+	listing.OriginalTotalPrice.Currency = currency
+	listing.OriginalSellingPrice.Currency = currency
+	listing.PreviousSellingPrice.Currency = currency
+	listing.TotalPriceFeeApplicable.Currency = currency
+	listing.ReportedTwicketsFeePerTicket.Currency = currency
+
+	record.FirstSeenAt = time.UnixMilli(firstSeenAt)
+	record.LastSeenAt = time.UnixMilli(lastSeenAt)
+	if delistedAt.Valid {
+		delistedAtTime := time.UnixMilli(delistedAt.Int64)
+		record.DelistedAt = &delistedAtTime
+	}
+
+	event.Date.Time, err = time.Parse(dateLayout, eventDate)
+	if err != nil {
+		return store.Record{}, fmt.Errorf("invalid event date: %w", err)
+	}
+	event.Time.Time, err = time.Parse(timeLayout, eventStartTime)
+	if err != nil {
+		return store.Record{}, fmt.Errorf("invalid event start time: %w", err)
+	}
+	event.OnSale = parseNullableDateTime(onSaleAt)
+	event.Announced = parseNullableDateTime(announcedAt)
+
+	// Unknown country and region codes are kept, as they are when decoding the feed
+	location.Country = twigots.Country{Value: countryCode}
+	location.Region = twigots.Region{Value: regionCode}
+
+	tour.FirstEvent, err = parseNullableDate(tourFirstEvent)
+	if err != nil {
+		return store.Record{}, fmt.Errorf("invalid tour first event date: %w", err)
+	}
+	tour.LastEvent, err = parseNullableDate(tourLastEvent)
+	if err != nil {
+		return store.Record{}, fmt.Errorf("invalid tour last event date: %w", err)
+	}
+	var tourCountryCodes []string
+	err = json.Unmarshal([]byte(tourCountries), &tourCountryCodes)
+	if err != nil {
+		return store.Record{}, fmt.Errorf("invalid tour countries: %w", err)
+	}
+	if tourCountryCodes != nil {
+		tour.Countries = make([]twigots.Country, 0, len(tourCountryCodes))
+		for _, countryCode := range tourCountryCodes {
+			tour.Countries = append(tour.Countries, twigots.Country{Value: countryCode})
+		}
+	}
+
+	return record, nil
+}
+
+// history gets the history of each of the listings with the specified ids, oldest first.
+func (s *Store) history(ctx context.Context, listingIds []string) (map[string][]store.HistoryEntry, error) {
+	historyByListing := make(map[string][]store.HistoryEntry, len(listingIds))
+	if len(listingIds) == 0 {
+		return historyByListing, nil
+	}
+
+	placeholders, args := inPlaceholders(listingIds)
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT listing_id, seen_at, num_tickets, currency, total_price_excl_fee, twickets_fee
+		FROM listing_history
+		WHERE listing_id IN (`+placeholders+`)
+		ORDER BY seen_at, rowid`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listingId, currencyCode string
+		var seenAt int64
+		var entry store.HistoryEntry
+		err := rows.Scan(
+			&listingId, &seenAt, &entry.NumTickets, &currencyCode,
+			&entry.TotalPriceExclFee.Amount, &entry.TwicketsFee.Amount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history: %w", err)
+		}
+
+		currency := parseCurrency(currencyCode)
+		entry.SeenAt = time.UnixMilli(seenAt)
+		entry.TotalPriceExclFee.Currency = currency
+		entry.TwicketsFee.Currency = currency
+		historyByListing[listingId] = append(historyByListing[listingId], entry)
+	}
+
+	return historyByListing, rows.Err()
+}
+
+// lineups gets the lineup of each of the events with the specified ids, ordered by billing.
+func (s *Store) lineups(ctx context.Context, eventIds []string) (map[string][]twigots.Lineup, error) {
+	lineupByEvent := make(map[string][]twigots.Lineup, len(eventIds))
+	if len(eventIds) == 0 {
+		return lineupByEvent, nil
+	}
+
+	placeholders, args := inPlaceholders(eventIds)
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT lu.event_id, lu.billing, a.id, a.name, a.slug
+		FROM lineup lu
+		JOIN artists a ON a.id = lu.artist_id
+		WHERE lu.event_id IN (`+placeholders+`)
+		ORDER BY lu.event_id, lu.billing`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lineups: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventId string
+		var lineup twigots.Lineup
+		err := rows.Scan(&eventId, &lineup.Billing, &lineup.Artist.Id, &lineup.Artist.Name, &lineup.Artist.Slug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lineup: %w", err)
+		}
+		lineupByEvent[eventId] = append(lineupByEvent[eventId], lineup)
+	}
+
+	return lineupByEvent, rows.Err()
+}
+
+// inPlaceholders creates the placeholders and args for an SQL IN clause, removing any duplicate values.
+func inPlaceholders(values []string) (string, []any) {
+	seen := make(map[string]struct{}, len(values))
+	placeholders := make([]string, 0, len(values))
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		placeholders = append(placeholders, "?")
+		args = append(args, value)
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// parseCurrency parses a currency code, keeping unknown codes as they are when decoding the feed.
+func parseCurrency(currencyCode string) twigots.Currency {
+	return twigots.Currency{Value: currencyCode}
+}
+
+func nullableDateTime(dateTime *twigots.DateTime) sql.NullInt64 {
+	if dateTime == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: dateTime.UnixMilli(), Valid: true}
+}
+
+func parseNullableDateTime(value sql.NullInt64) *twigots.DateTime {
+	if !value.Valid {
+		return nil
+	}
+	return &twigots.DateTime{Time: time.UnixMilli(value.Int64).UTC()}
+}
+
+func nullableDate(date *twigots.Date) sql.NullString {
+	if date == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: date.Format(dateLayout), Valid: true}
+}
+
+func parseNullableDate(value sql.NullString) (*twigots.Date, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	date, err := time.Parse(dateLayout, value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &twigots.Date{Time: date}, nil
+}
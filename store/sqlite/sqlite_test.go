@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/ahobsonsayers/twigots/store"
+	"github.com/ahobsonsayers/utilopia/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	databasePath := filepath.Join(t.TempDir(), "twigots.db")
+	testTime := time.Date(2024, 6, 6, 12, 0, 0, 0, time.UTC)
+	listings := testTicketListings(t)
+
+	db, err := Open(ctx, databasePath)
+	require.NoError(t, err)
+
+	err = db.Upsert(ctx, testTime, listings...)
+	require.NoError(t, err)
+
+	// Seeing the same listings again should not add history
+	err = db.Upsert(ctx, testTime.Add(time.Minute), listings...)
+	require.NoError(t, err)
+
+	// Price drop should add history
+	reducedListing := listings[0]
+	reducedListing.TotalPriceExclFee.Amount -= 1000
+	err = db.Upsert(ctx, testTime.Add(2*time.Minute), reducedListing)
+	require.NoError(t, err)
+
+	err = db.Delist(ctx, listings[1].Id, testTime.Add(3*time.Minute))
+	require.NoError(t, err)
+
+	// Reopen database to check everything is persisted and migrations are not reapplied
+	err = db.Close()
+	require.NoError(t, err)
+	db, err = Open(ctx, databasePath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	record, err := db.Get(ctx, listings[0].Id)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	require.False(t, record.Delisted())
+	require.Equal(t, testTime, record.FirstSeenAt.UTC())
+	require.Equal(t, testTime.Add(2*time.Minute), record.LastSeenAt.UTC())
+	require.Len(t, record.History, 2)
+	require.Equal(t, "£218.25", record.History[0].TotalPriceInclFee().String())
+	require.Equal(t, "£208.25", record.History[1].TotalPriceInclFee().String())
+
+	// Check listing is correctly reconstructed
+	listing := record.Listing
+	require.Equal(t, reducedListing.CreatedAt.UnixMilli(), listing.CreatedAt.UnixMilli())
+	require.Equal(t, reducedListing.TotalPriceExclFee, listing.TotalPriceExclFee)
+	require.Equal(t, reducedListing.TwicketsFee, listing.TwicketsFee)
+	require.Equal(t, reducedListing.OriginalTotalPrice, listing.OriginalTotalPrice)
+	require.Equal(t, reducedListing.PriceChange(), listing.PriceChange())
+	require.Equal(t, reducedListing.TicketType, listing.TicketType)
+	require.Equal(t, reducedListing.Event.Name, listing.Event.Name)
+	require.Equal(t, reducedListing.Event.Date, listing.Event.Date)
+	require.Equal(t, reducedListing.Event.Time, listing.Event.Time)
+	require.Equal(t, reducedListing.Event.Lineup, listing.Event.Lineup)
+	require.Equal(t, reducedListing.Event.Venue, listing.Event.Venue)
+	require.Equal(t, reducedListing.Tour, listing.Tour)
+
+	record, err = db.Get(ctx, listings[1].Id)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	require.True(t, record.Delisted())
+
+	record, err = db.Get(ctx, "does not exist")
+	require.NoError(t, err)
+	require.Nil(t, record)
+
+	// Query by region
+	records, err := db.Query(ctx, store.Query{Region: twigots.RegionLondon})
+	require.NoError(t, err)
+	require.Len(t, records, 2) // Mean Girls is delisted
+	require.Equal(t, "Foo Fighters", records[0].Listing.Event.Name)
+	require.Equal(t, "South Africa v Wales", records[1].Listing.Event.Name)
+
+	records, err = db.Query(ctx, store.Query{Region: twigots.RegionLondon, IncludeDelisted: true})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// Query by event and created time
+	records, err = db.Query(ctx, store.Query{EventId: listings[3].Event.Id})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	records, err = db.Query(ctx, store.Query{CreatedAfter: listings[0].CreatedAt.Time})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "Download Festival 2024", records[0].Listing.Event.Name)
+
+	// Typed query helpers
+	history, err := db.PriceHistory(ctx, listings[0].Id)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	summaries, err := db.EventSummaries(ctx, store.Query{IncludeDelisted: true})
+	require.NoError(t, err)
+	require.Len(t, summaries, 4)
+}
+
+func TestStoreEventSummariesCurrencies(t *testing.T) {
+	ctx := context.Background()
+	testTime := time.Date(2024, 6, 6, 12, 0, 0, 0, time.UTC)
+
+	// Two listings of the same event in different currencies
+	gbpListing := testTicketListings(t)[0]
+	eurListing := gbpListing
+	eurListing.Id = "eur"
+	eurListing.TotalPriceExclFee = twigots.Price{Currency: twigots.CurrencyEUR, Amount: 100}
+	eurListing.TwicketsFee = twigots.Price{Currency: twigots.CurrencyEUR, Amount: 10}
+
+	db, err := Open(ctx, filepath.Join(t.TempDir(), "twigots.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Upsert(ctx, testTime, gbpListing, eurListing)
+	require.NoError(t, err)
+
+	// Each currency should be summarised separately
+	summaries, err := db.EventSummaries(ctx, store.Query{})
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	require.Equal(t, gbpListing.Event.Id, summaries[0].EventId)
+	require.Equal(t, gbpListing.Event.Id, summaries[1].EventId)
+
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 110}, summaries[0].MinTotalPriceInclFee)
+	require.Equal(t, twigots.Price{Currency: twigots.CurrencyEUR, Amount: 110}, summaries[0].MaxTotalPriceInclFee)
+
+	require.Equal(t, gbpListing.TotalPriceInclFee(), summaries[1].MinTotalPriceInclFee)
+	require.Equal(t, gbpListing.TotalPriceInclFee(), summaries[1].MaxTotalPriceInclFee)
+}
+
+func TestStoreFeeDrift(t *testing.T) {
+	ctx := context.Background()
+	testTime := time.Date(2024, 6, 6, 12, 0, 0, 0, time.UTC)
+
+	// Reported fee per ticket differs from the fee calculated from the total fee
+	listing := testTicketListings(t)[0]
+	listing.TotalPriceFeeApplicable = listing.TotalPriceExclFee.Subtract(
+		twigots.Price{Currency: listing.TotalPriceExclFee.Currency, Amount: 500},
+	)
+	listing.ReportedTwicketsFeePerTicket = listing.TwicketsFeePerTicket().Add(
+		twigots.Price{Currency: listing.TwicketsFee.Currency, Amount: 100},
+	)
+	require.True(t, listing.Breakdown().HasFeeDrift())
+
+	db, err := Open(ctx, filepath.Join(t.TempDir(), "twigots.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = db.Upsert(ctx, testTime, listing)
+	require.NoError(t, err)
+
+	record, err := db.Get(ctx, listing.Id)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	require.Equal(t, listing.TotalPriceFeeApplicable, record.Listing.TotalPriceFeeApplicable)
+	require.Equal(t, listing.ReportedTwicketsFeePerTicket, record.Listing.ReportedTwicketsFeePerTicket)
+	require.Equal(t, listing.Breakdown(), record.Listing.Breakdown())
+	require.True(t, record.Listing.Breakdown().HasFeeDrift())
+}
+
+func testTicketListings(t *testing.T) twigots.TicketListings {
+	feedJson, err := os.ReadFile(testutils.ProjectDirectoryJoin(t, "test", "data", "fullFeedResponse.json"))
+	require.NoError(t, err)
+
+	listings, err := twigots.UnmarshalTwicketsFeedJson(feedJson)
+	require.NoError(t, err)
+
+	return listings
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// Store is a persistent store of ticket listings and their history.
+type Store interface {
+	// Upsert inserts ticket listings, or updates them if they already exist.
+	// Listings are keyed by TicketListing.Id.
+	// seenAt is the time the listings were seen, and is used to record listing history.
+	Upsert(ctx context.Context, seenAt time.Time, listings ...twigots.TicketListing) error
+
+	// Delist records that the ticket listing with the specified id was delisted at the specified time.
+	// Delisting a listing that does not exist is not an error.
+	Delist(ctx context.Context, id string, delistedAt time.Time) error
+
+	// Get gets the record of the ticket listing with the specified id,
+	// or returns nil if one does not exist.
+	Get(ctx context.Context, id string) (*Record, error)
+
+	// Query gets the records of all ticket listings matching the query.
+	// Records are returned newest first, ordered by listing creation time.
+	Query(ctx context.Context, query Query) ([]Record, error)
+
+	// Close the store, releasing any resources.
+	Close() error
+}
+
+// Record is the stored record of a ticket listing.
+type Record struct {
+	// Listing is the latest seen version of the ticket listing.
+	Listing twigots.TicketListing `json:"listing"`
+
+	FirstSeenAt time.Time  `json:"firstSeenAt"`
+	LastSeenAt  time.Time  `json:"lastSeenAt"`
+	DelistedAt  *time.Time `json:"delistedAt,omitempty"` // Nil if the listing has not been delisted
+
+	// History of the listing. A new entry is added every time the price or
+	// number of tickets in the listing changes. The first entry is when the listing was first seen.
+	History []HistoryEntry `json:"history"`
+}
+
+// Delisted returns whether the ticket listing has been delisted.
+func (r Record) Delisted() bool {
+	return r.DelistedAt != nil
+}
+
+// HistoryEntry is a point in the history of a ticket listing.
+type HistoryEntry struct {
+	SeenAt            time.Time     `json:"seenAt"`
+	NumTickets        int           `json:"numTickets"`
+	TotalPriceExclFee twigots.Price `json:"totalPriceExclFee"`
+	TwicketsFee       twigots.Price `json:"twicketsFee"`
+}
+
+// TotalPriceInclFee is the total price of all tickets, including fee, at this point in history.
+func (e HistoryEntry) TotalPriceInclFee() twigots.Price {
+	return e.TotalPriceExclFee.Add(e.TwicketsFee)
+}
+
+func newHistoryEntry(listing twigots.TicketListing, seenAt time.Time) HistoryEntry {
+	return HistoryEntry{
+		SeenAt:            seenAt,
+		NumTickets:        listing.NumTickets,
+		TotalPriceExclFee: listing.TotalPriceExclFee,
+		TwicketsFee:       listing.TwicketsFee,
+	}
+}
+
+// Query defines parameters when querying records of ticket listings.
+// All set fields must match for a record to be returned.
+type Query struct {
+	// EventId of the ticket listings. Leave empty to match any event.
+	EventId string
+
+	// TourId of the ticket listings. Leave empty to match any tour.
+	TourId string
+
+	// Region of the ticket listings event venue. Leave unset to match any region.
+	Region twigots.Region
+
+	// CreatedAfter is the time which ticket listings must have been created after.
+	// Leave as zero time to match any creation time.
+	CreatedAfter time.Time
+
+	// CreatedBefore is the time which ticket listings must have been created before.
+	// Leave as zero time to match any creation time.
+	CreatedBefore time.Time
+
+	// IncludeDelisted determines whether records of delisted ticket listings are returned.
+	// Defaults to false.
+	IncludeDelisted bool
+}
+
+// Matches checks whether a record matches the query.
+func (q Query) Matches(record Record) bool {
+	listing := record.Listing
+	if q.EventId != "" && listing.Event.Id != q.EventId {
+		return false
+	}
+	if q.TourId != "" && listing.Tour.Id != q.TourId {
+		return false
+	}
+	if q.Region.Value != "" && listing.Event.Venue.Location.Region != q.Region {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && !listing.CreatedAt.After(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !listing.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	if !q.IncludeDelisted && record.Delisted() {
+		return false
+	}
+	return true
+}
+
+// updateRecord updates a record with a newly seen version of its ticket listing.
+// If the record is nil, a new record is created.
+func updateRecord(record *Record, listing twigots.TicketListing, seenAt time.Time) *Record {
+	if record == nil {
+		return &Record{
+			Listing:     listing,
+			FirstSeenAt: seenAt,
+			LastSeenAt:  seenAt,
+			History:     []HistoryEntry{newHistoryEntry(listing, seenAt)},
+		}
+	}
+
+	record.Listing = listing
+	if seenAt.After(record.LastSeenAt) {
+		record.LastSeenAt = seenAt
+	}
+
+	// If listing has been seen again after being delisted, it has been relisted
+	if record.DelistedAt != nil && seenAt.After(*record.DelistedAt) {
+		record.DelistedAt = nil
+	}
+
+	// Add history entry if anything has changed
+	latestEntry := record.History[len(record.History)-1]
+	newEntry := newHistoryEntry(listing, seenAt)
+	if latestEntry.NumTickets != newEntry.NumTickets ||
+		latestEntry.TotalPriceExclFee != newEntry.TotalPriceExclFee ||
+		latestEntry.TwicketsFee != newEntry.TwicketsFee {
+		record.History = append(record.History, newEntry)
+	}
+
+	return record
+}
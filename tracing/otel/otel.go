@@ -0,0 +1,68 @@
+// Package otel traces twigots client requests using OpenTelemetry.
+//
+// Usage:
+//
+//	tracer := otel.NewTracer(tracerProvider.Tracer("twigots"))
+//	client, err := twigots.NewClient(apiKey, twigots.WithTracer(tracer))
+package otel
+
+import (
+	"context"
+
+	"github.com/ahobsonsayers/twigots"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of feed page request spans
+const FeedRequestSpanName = "twigots.feed_request"
+
+// Attributes set on feed page request spans
+const (
+	AttributeMaxTime     = attribute.Key("twigots.max_time")     // Unix milliseconds
+	AttributeRegionQuery = attribute.Key("twigots.region_query") // e.g. countryCode=GB,regionCode=GBLO
+	AttributeResultCount = attribute.Key("twigots.result_count")
+	AttributeStatusCode  = attribute.Key("http.response.status_code")
+)
+
+// Tracer traces twigots client requests using OpenTelemetry. It implements twigots.Tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ twigots.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a tracer that creates spans using an OpenTelemetry tracer.
+func NewTracer(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// StartFeedRequest starts a client span around a feed page request.
+func (t *Tracer) StartFeedRequest(
+	ctx context.Context,
+	request twigots.FeedRequest,
+) (context.Context, func(twigots.FeedResponse)) {
+	attributes := []attribute.KeyValue{AttributeRegionQuery.String(request.Query)}
+	if !request.MaxTime.IsZero() {
+		attributes = append(attributes, AttributeMaxTime.Int64(request.MaxTime.UnixMilli()))
+	}
+
+	ctx, span := t.tracer.Start(
+		ctx, FeedRequestSpanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+
+	return ctx, func(response twigots.FeedResponse) {
+		span.SetAttributes(AttributeResultCount.Int(response.NumListings))
+		if response.StatusCode != 0 {
+			span.SetAttributes(AttributeStatusCode.Int(response.StatusCode))
+		}
+		if response.Err != nil {
+			span.RecordError(response.Err)
+			span.SetStatus(codes.Error, response.Err.Error())
+		}
+		span.End()
+	}
+}
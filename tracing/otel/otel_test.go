@@ -0,0 +1,46 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewTracer(provider.Tracer("test"))
+
+	maxTime := time.UnixMilli(1717671137420)
+	_, end := tracer.StartFeedRequest(context.Background(), twigots.FeedRequest{
+		Query:   "countryCode=GB,regionCode=GBLO",
+		MaxTime: maxTime,
+	})
+	end(twigots.FeedResponse{StatusCode: 200, NumListings: 10})
+
+	_, end = tracer.StartFeedRequest(context.Background(), twigots.FeedRequest{Query: "countryCode=GB"})
+	end(twigots.FeedResponse{StatusCode: 403, Err: errors.New("forbidden")})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	require.Equal(t, FeedRequestSpanName, spans[0].Name())
+	require.ElementsMatch(t, []attribute.KeyValue{
+		AttributeRegionQuery.String("countryCode=GB,regionCode=GBLO"),
+		AttributeMaxTime.Int64(1717671137420),
+		AttributeResultCount.Int(10),
+		AttributeStatusCode.Int(200),
+	}, spans[0].Attributes())
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+	require.Len(t, spans[1].Events(), 1) // Recorded error
+}
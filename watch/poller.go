@@ -0,0 +1,203 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+const (
+	// Default interval between polls
+	DefaultPollInterval = time.Minute
+
+	// Default maximum number of listings fetched in a single poll.
+	// This is large to ensure all new listings since the last poll are fetched,
+	// while preventing accidentally fetching too many listings.
+	DefaultPollMaxNumber = 250
+)
+
+// Fetcher fetches ticket listings. It is implemented by twigots.Client.
+type Fetcher interface {
+	FetchTicketListings(ctx context.Context, input twigots.FetchTicketListingsInput) (twigots.TicketListings, error)
+}
+
+// DelistFetcher fetches ticket listings along with delisted ticket listings.
+// It is implemented by twigots.Client.
+type DelistFetcher interface {
+	FetchTicketListingsAndDelists(
+		ctx context.Context, input twigots.FetchTicketListingsInput,
+	) (twigots.TicketListings, []twigots.Delist, error)
+}
+
+// HandlerFunc handles new ticket listings found by a poller.
+type HandlerFunc func(ctx context.Context, listings twigots.TicketListings) error
+
+// Poller repeatedly polls for new ticket listings.
+//
+// A high-water mark of the creation time of the newest listing seen is kept, and only
+// listings created after it are fetched on the next poll, so listings are only found once.
+// Persist the high-water mark (see HighWaterMark and SetHighWaterMark) to avoid finding
+// the same listings again after a restart.
+type Poller struct {
+	// Fetcher used to fetch ticket listings. Required.
+	Fetcher Fetcher
+
+	// Input used to fetch ticket listings. Country is required.
+	// CreatedAfter and CreatedBefore are ignored and set using the high-water mark.
+	// If MaxNumber is unset, DefaultPollMaxNumber is used.
+	Input twigots.FetchTicketListingsInput
+
+	// Interval between polls. Defaults to DefaultPollInterval.
+	Interval time.Duration
+
+	// HandleDelists is called with ticket listings delisted since the last poll.
+	// Requires the fetcher to implement DelistFetcher. If nil, delists are ignored.
+	HandleDelists func(ctx context.Context, delists []twigots.Delist) error
+
+	// OnError is called when polling for, or handling, new listings fails.
+	// Polling continues after an error. If nil, errors are ignored.
+	OnError func(error)
+
+	mutex         sync.Mutex
+	highWaterMark time.Time
+
+	// Time of the latest delist found, so delists are only found once
+	delistHighWaterMark time.Time
+}
+
+// HighWaterMark gets the creation time of the newest listing seen.
+func (p *Poller) HighWaterMark() time.Time {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.highWaterMark
+}
+
+// SetHighWaterMark sets the creation time of the newest listing seen.
+// Only listings created after this time will be found by the next poll.
+func (p *Poller) SetHighWaterMark(highWaterMark time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.highWaterMark = highWaterMark
+}
+
+// Poll once for ticket listings created after the high-water mark, updating the high-water mark.
+// Listings are returned newest first.
+//
+// If the high-water mark is unset, it is set to the current time and no listings are returned.
+func (p *Poller) Poll(ctx context.Context) (twigots.TicketListings, error) {
+	listings, _, err := p.poll(ctx)
+	return listings, err
+}
+
+// poll polls once for ticket listings, and delists if HandleDelists is set. See Poll.
+func (p *Poller) poll(ctx context.Context) (twigots.TicketListings, []twigots.Delist, error) {
+	if p.Fetcher == nil {
+		return nil, nil, errors.New("fetcher must be set")
+	}
+
+	now := time.Now()
+	highWaterMark := p.HighWaterMark()
+	if highWaterMark.IsZero() {
+		p.SetHighWaterMark(now)
+		return nil, nil, nil
+	}
+
+	input := p.Input
+	input.CreatedAfter = highWaterMark
+	input.CreatedBefore = now
+	if input.MaxNumber == 0 {
+		input.MaxNumber = DefaultPollMaxNumber
+	}
+
+	var listings twigots.TicketListings
+	var delists []twigots.Delist
+	var err error
+	delistFetcher, ok := p.Fetcher.(DelistFetcher)
+	if ok && p.HandleDelists != nil {
+		listings, delists, err = delistFetcher.FetchTicketListingsAndDelists(ctx, input)
+	} else {
+		listings, err = p.Fetcher.FetchTicketListings(ctx, input)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, listing := range listings {
+		if listing.CreatedAt.After(highWaterMark) {
+			highWaterMark = listing.CreatedAt.Time
+		}
+	}
+	p.SetHighWaterMark(highWaterMark)
+
+	return listings, p.newDelists(delists), nil
+}
+
+// newDelists returns the delists not found by a previous poll, updating the delist high-water mark.
+func (p *Poller) newDelists(delists []twigots.Delist) []twigots.Delist {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	newDelists := make([]twigots.Delist, 0, len(delists))
+	delistHighWaterMark := p.delistHighWaterMark
+	for _, delist := range delists {
+		if !delist.DelistedAt.IsZero() && !delist.DelistedAt.After(p.delistHighWaterMark) {
+			continue
+		}
+		if delist.DelistedAt.After(delistHighWaterMark) {
+			delistHighWaterMark = delist.DelistedAt
+		}
+		newDelists = append(newDelists, delist)
+	}
+	p.delistHighWaterMark = delistHighWaterMark
+
+	return newDelists
+}
+
+// Run polls for new ticket listings until the context is cancelled, calling handle
+// with any new listings found, and HandleDelists with any delists found.
+// Run polls immediately, and then every interval.
+//
+// Returns the context error once the context is cancelled.
+func (p *Poller) Run(ctx context.Context, handle HandlerFunc) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		listings, delists, err := p.poll(ctx)
+		if err != nil {
+			p.onError(err)
+		}
+		if len(listings) != 0 {
+			err := handle(ctx, listings)
+			if err != nil {
+				p.onError(err)
+			}
+		}
+		if len(delists) != 0 {
+			err := p.HandleDelists(ctx, delists)
+			if err != nil {
+				p.onError(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) onError(err error) {
+	if p.OnError != nil && !errors.Is(err, context.Canceled) {
+		p.OnError(err)
+	}
+}
@@ -0,0 +1,155 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetcher returns listings created after the input created after time, along with all delists.
+type fakeFetcher struct {
+	listings twigots.TicketListings
+	delists  []twigots.Delist
+	inputs   []twigots.FetchTicketListingsInput
+	err      error
+}
+
+func (f *fakeFetcher) FetchTicketListingsAndDelists(
+	ctx context.Context,
+	input twigots.FetchTicketListingsInput,
+) (twigots.TicketListings, []twigots.Delist, error) {
+	listings, err := f.FetchTicketListings(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return listings, f.delists, nil
+}
+
+func (f *fakeFetcher) FetchTicketListings(
+	_ context.Context,
+	input twigots.FetchTicketListingsInput,
+) (twigots.TicketListings, error) {
+	f.inputs = append(f.inputs, input)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var listings twigots.TicketListings
+	for _, listing := range f.listings {
+		if listing.CreatedAt.After(input.CreatedAfter) {
+			listings = append(listings, listing)
+		}
+	}
+	return listings, nil
+}
+
+func TestPollerPoll(t *testing.T) {
+	ctx := context.Background()
+	fetcher := &fakeFetcher{}
+	poller := &Poller{
+		Fetcher: fetcher,
+		Input:   twigots.FetchTicketListingsInput{Country: twigots.CountryUnitedKingdom},
+	}
+
+	// First poll should set high-water mark and return nothing
+	listings, err := poller.Poll(ctx)
+	require.NoError(t, err)
+	require.Empty(t, listings)
+	require.Empty(t, fetcher.inputs)
+	startTime := poller.HighWaterMark()
+	require.False(t, startTime.IsZero())
+
+	// New listings should be returned, and high-water mark updated
+	fetcher.listings = twigots.TicketListings{
+		testListingCreatedAt("2", startTime.Add(2*time.Second)),
+		testListingCreatedAt("1", startTime.Add(time.Second)),
+	}
+	listings, err = poller.Poll(ctx)
+	require.NoError(t, err)
+	require.Len(t, listings, 2)
+	require.Equal(t, startTime.Add(2*time.Second), poller.HighWaterMark())
+	require.Equal(t, startTime, fetcher.inputs[0].CreatedAfter)
+	require.Equal(t, DefaultPollMaxNumber, fetcher.inputs[0].MaxNumber)
+
+	// Same listings should not be returned again
+	listings, err = poller.Poll(ctx)
+	require.NoError(t, err)
+	require.Empty(t, listings)
+	require.Equal(t, startTime.Add(2*time.Second), poller.HighWaterMark())
+
+	// Errors should not update high-water mark
+	fetcher.err = errors.New("failed")
+	_, err = poller.Poll(ctx)
+	require.Error(t, err)
+	require.Equal(t, startTime.Add(2*time.Second), poller.HighWaterMark())
+}
+
+func TestPollerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	startTime := time.Now().Add(-time.Minute)
+	fetcher := &fakeFetcher{
+		listings: twigots.TicketListings{testListingCreatedAt("1", startTime.Add(time.Second))},
+	}
+	poller := &Poller{
+		Fetcher:  fetcher,
+		Input:    twigots.FetchTicketListingsInput{Country: twigots.CountryUnitedKingdom},
+		Interval: time.Millisecond,
+	}
+	poller.SetHighWaterMark(startTime)
+
+	var handled twigots.TicketListings
+	err := poller.Run(ctx, func(_ context.Context, listings twigots.TicketListings) error {
+		handled = append(handled, listings...)
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, handled, 1)
+}
+
+func TestPollerRunDelists(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now().Add(-time.Minute)
+	fetcher := &fakeFetcher{
+		listings: twigots.TicketListings{testListingCreatedAt("1", startTime.Add(time.Second))},
+		delists: []twigots.Delist{
+			{Id: "2", DelistedAt: startTime.Add(2 * time.Second)},
+			{Id: "3", DelistedAt: startTime.Add(3 * time.Second)},
+		},
+	}
+
+	var handled []twigots.Delist
+	poller := &Poller{
+		Fetcher:  fetcher,
+		Input:    twigots.FetchTicketListingsInput{Country: twigots.CountryUnitedKingdom},
+		Interval: time.Millisecond,
+		HandleDelists: func(_ context.Context, delists []twigots.Delist) error {
+			handled = append(handled, delists...)
+			return nil
+		},
+	}
+	poller.SetHighWaterMark(startTime)
+
+	// Delists should only be handled once, even though they are fetched every poll
+	err := poller.Run(ctx, func(_ context.Context, _ twigots.TicketListings) error {
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Greater(t, len(fetcher.inputs), 1)
+	require.Equal(t, fetcher.delists, handled)
+}
+
+func testListingCreatedAt(id string, createdAt time.Time) twigots.TicketListing {
+	return twigots.TicketListing{
+		Id:        id,
+		CreatedAt: twigots.UnixTime{Time: createdAt},
+	}
+}
@@ -0,0 +1,149 @@
+package watch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+)
+
+// PriceDropped is an event fired when a ticket listing that has already been
+// seen is seen again with a lower total price (including fee).
+type PriceDropped struct {
+	Listing twigots.TicketListing
+
+	// PreviousTotalPriceInclFee is the total price of the listing, including fee, when it was last seen.
+	PreviousTotalPriceInclFee twigots.Price
+
+	// CurrentTotalPriceInclFee is the current total price of the listing, including fee.
+	CurrentTotalPriceInclFee twigots.Price
+}
+
+// Drop is the amount the total price of the listing, including fee, has dropped by.
+func (e PriceDropped) Drop() twigots.Price {
+	return e.PreviousTotalPriceInclFee.Subtract(e.CurrentTotalPriceInclFee)
+}
+
+// Hooks are functions called by a Watcher when ticket listing events occur.
+// Any hook can be left nil if the event is not of interest.
+type Hooks struct {
+	// OnNewListing is called when a ticket listing is seen for the first time.
+	OnNewListing func(twigots.TicketListing)
+
+	// OnPriceDropped is called when a ticket listing that has already been seen is
+	// seen again with a lower total price (including fee).
+	OnPriceDropped func(PriceDropped)
+}
+
+// DefaultSeenExpiry is the default time after which a watcher forgets a listing it has not seen again.
+const DefaultSeenExpiry = 7 * 24 * time.Hour
+
+// Watcher keeps track of the ticket listings it has seen, and calls hooks
+// when it sees new listings or listings whose price has dropped.
+//
+// Listings not seen again within SeenExpiry are forgotten, so memory use does not grow
+// without limit. A forgotten listing is treated as new if it is seen again.
+//
+// It is safe to use a Watcher from multiple goroutines.
+type Watcher struct {
+	// SeenExpiry is the time after which a listing that has not been seen again is forgotten.
+	// Defaults to DefaultSeenExpiry. Set to a negative duration to never forget listings.
+	SeenExpiry time.Duration
+
+	hooks Hooks
+	now   func() time.Time
+
+	mutex sync.Mutex
+	seen  map[string]seenListing // Keyed by listing id
+}
+
+// seenListing is a listing that has been seen by a watcher.
+type seenListing struct {
+	totalPriceInclFee twigots.Price
+	lastSeenAt        time.Time
+}
+
+// NewWatcher creates a new watcher that calls the specified hooks.
+func NewWatcher(hooks Hooks) *Watcher {
+	return &Watcher{
+		SeenExpiry: DefaultSeenExpiry,
+		hooks:      hooks,
+		now:        time.Now,
+		seen:       make(map[string]seenListing),
+	}
+}
+
+// Observe ticket listings, calling hooks for any listings that are new or whose price has dropped.
+// Hooks are called synchronously, in the order the listings are provided. Hooks are called
+// after the listings have been recorded as seen, so can safely call other methods of the watcher.
+//
+// A price drop is only reported if the previous and current prices are in the same currency.
+func (w *Watcher) Observe(listings ...twigots.TicketListing) {
+	for _, callHook := range w.observe(listings) {
+		callHook()
+	}
+}
+
+// observe records listings as seen, returning the hook calls to make once the watcher is unlocked.
+func (w *Watcher) observe(listings []twigots.TicketListing) []func() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	now := w.now()
+	w.evict(now)
+
+	var hookCalls []func()
+	for _, listing := range listings {
+		currentPrice := listing.TotalPriceInclFee()
+		previous, seen := w.seen[listing.Id]
+		w.seen[listing.Id] = seenListing{totalPriceInclFee: currentPrice, lastSeenAt: now}
+
+		if !seen {
+			if w.hooks.OnNewListing != nil {
+				hookCalls = append(hookCalls, func() { w.hooks.OnNewListing(listing) })
+			}
+			continue
+		}
+
+		// Prices in different currencies cannot be compared, so are not price drops
+		previousPrice := previous.totalPriceInclFee
+		priceCmp, err := currentPrice.CheckedCmp(previousPrice)
+		if err == nil && priceCmp < 0 && w.hooks.OnPriceDropped != nil {
+			event := PriceDropped{
+				Listing:                   listing,
+				PreviousTotalPriceInclFee: previousPrice,
+				CurrentTotalPriceInclFee:  currentPrice,
+			}
+			hookCalls = append(hookCalls, func() { w.hooks.OnPriceDropped(event) })
+		}
+	}
+
+	return hookCalls
+}
+
+// evict forgets listings that have not been seen within the seen expiry.
+func (w *Watcher) evict(now time.Time) {
+	if w.SeenExpiry < 0 {
+		return
+	}
+
+	seenExpiry := w.SeenExpiry
+	if seenExpiry == 0 {
+		seenExpiry = DefaultSeenExpiry
+	}
+
+	for id, seen := range w.seen {
+		if now.Sub(seen.lastSeenAt) > seenExpiry {
+			delete(w.seen, id)
+		}
+	}
+}
+
+// Seen returns whether a ticket listing with the specified id has been seen.
+func (w *Watcher) Seen(id string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, seen := w.seen[id]
+	return seen
+}
@@ -0,0 +1,118 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahobsonsayers/twigots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	var newListings []twigots.TicketListing
+	var priceDrops []PriceDropped
+	watcher := NewWatcher(Hooks{
+		OnNewListing:   func(listing twigots.TicketListing) { newListings = append(newListings, listing) },
+		OnPriceDropped: func(event PriceDropped) { priceDrops = append(priceDrops, event) },
+	})
+
+	watcher.Observe(testListing("1", 100), testListing("2", 50))
+	require.Len(t, newListings, 2)
+	require.Empty(t, priceDrops)
+	require.True(t, watcher.Seen("1"))
+	require.False(t, watcher.Seen("3"))
+
+	// Seeing the same listings again, or at a higher price, should not fire any hooks
+	watcher.Observe(testListing("1", 100), testListing("2", 60))
+	require.Len(t, newListings, 2)
+	require.Empty(t, priceDrops)
+
+	// Price drop should fire hook
+	watcher.Observe(testListing("1", 80))
+	require.Len(t, newListings, 2)
+	require.Len(t, priceDrops, 1)
+	require.Equal(t, "1", priceDrops[0].Listing.Id)
+	require.Equal(t, "£110.00", priceDrops[0].PreviousTotalPriceInclFee.String())
+	require.Equal(t, "£88.00", priceDrops[0].CurrentTotalPriceInclFee.String())
+	require.Equal(t, "£22.00", priceDrops[0].Drop().String())
+}
+
+func TestWatcherNilHooks(t *testing.T) {
+	watcher := NewWatcher(Hooks{})
+	watcher.Observe(testListing("1", 100))
+	watcher.Observe(testListing("1", 50))
+	require.True(t, watcher.Seen("1"))
+}
+
+func TestWatcherHooksCanUseWatcher(t *testing.T) {
+	var watcher *Watcher
+	var seenInHook bool
+	watcher = NewWatcher(Hooks{
+		OnNewListing: func(listing twigots.TicketListing) {
+			// This would deadlock if hooks were called while the watcher is locked
+			seenInHook = watcher.Seen(listing.Id)
+			watcher.Observe(listing)
+		},
+	})
+
+	watcher.Observe(testListing("1", 100))
+	require.True(t, seenInHook)
+}
+
+func TestWatcherPriceDropDifferentCurrency(t *testing.T) {
+	var priceDrops []PriceDropped
+	watcher := NewWatcher(Hooks{
+		OnPriceDropped: func(event PriceDropped) { priceDrops = append(priceDrops, event) },
+	})
+
+	watcher.Observe(testListing("1", 100))
+
+	// A lower amount in a different currency is not a price drop
+	listing := testListing("1", 50)
+	listing.TotalPriceExclFee.Currency = twigots.CurrencyEUR
+	listing.TwicketsFee.Currency = twigots.CurrencyEUR
+	watcher.Observe(listing)
+	require.Empty(t, priceDrops)
+}
+
+func TestWatcherSeenExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	var newListings []twigots.TicketListing
+	watcher := NewWatcher(Hooks{
+		OnNewListing: func(listing twigots.TicketListing) { newListings = append(newListings, listing) },
+	})
+	watcher.SeenExpiry = time.Hour
+	watcher.now = func() time.Time { return now }
+
+	watcher.Observe(testListing("1", 100), testListing("2", 100))
+
+	// Listings seen again should not expire
+	now = now.Add(45 * time.Minute)
+	watcher.Observe(testListing("1", 100))
+
+	now = now.Add(45 * time.Minute)
+	watcher.Observe()
+	require.True(t, watcher.Seen("1"))
+	require.False(t, watcher.Seen("2"))
+
+	// Forgotten listings are new if seen again
+	watcher.Observe(testListing("2", 100))
+	require.Len(t, newListings, 3)
+}
+
+// testListing creates a test listing. Price is specified in pounds.
+// The twickets fee is 10% of the total price excluding fee.
+func testListing(id string, totalPrice int) twigots.TicketListing {
+	return twigots.TicketListing{
+		Id:         id,
+		NumTickets: 1,
+		TotalPriceExclFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 100,
+		},
+		TwicketsFee: twigots.Price{
+			Currency: twigots.CurrencyGBP,
+			Amount:   int64(totalPrice) * 10,
+		},
+	}
+}